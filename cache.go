@@ -0,0 +1,110 @@
+package smolder
+
+import (
+	"container/list"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Cache stores the values resolved for keys between loads. The values are
+// shared by all the loads that get them from the cache, so they should not be
+// modified. Cache implementations must be safe for concurrent use.
+//
+// The keys passed to a Cache are CacheKeys, so one Cache can be shared by
+// multiple resolvers.
+type Cache interface {
+	Get(key interface{}) (interface{}, bool)
+	Set(key interface{}, value interface{})
+}
+
+// CacheKey is the key of the values of a resolver in a Cache.
+type CacheKey struct {
+	// the name of the resolver, empty for unnamed resolvers
+	Name string
+	// the resolved type
+	Type reflect.Type
+	Key  interface{}
+}
+
+// Returns the key of the values resolved for key in the cache.
+func (r *resolver) cacheKey(key interface{}) CacheKey {
+	return CacheKey{Name: r.name, Type: r.typ.Elem(), Key: key}
+}
+
+// MemoryCache is an in-memory Cache evicting entries older than its TTL and
+// the least recently used entries once it is full.
+type MemoryCache struct {
+	ttl  time.Duration
+	size int
+
+	mu      sync.Mutex
+	entries map[interface{}]*list.Element
+	lru     *list.List
+}
+
+type memoryCacheEntry struct {
+	key     interface{}
+	value   interface{}
+	expires time.Time
+}
+
+// NewMemoryCache creates a MemoryCache holding entries for ttl and at most
+// size entries. ttl <= 0 means the entries never expire, size <= 0 means the
+// cache is not bounded.
+func NewMemoryCache(ttl time.Duration, size int) *MemoryCache {
+	return &MemoryCache{
+		ttl:     ttl,
+		size:    size,
+		entries: map[interface{}]*list.Element{},
+		lru:     list.New(),
+	}
+}
+
+func (c *MemoryCache) Get(key interface{}) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := el.Value.(*memoryCacheEntry)
+	if c.ttl > 0 && time.Now().After(e.expires) {
+		c.lru.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(el)
+	return e.value, true
+}
+
+func (c *MemoryCache) Set(key interface{}, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := &memoryCacheEntry{key: key, value: value, expires: time.Now().Add(c.ttl)}
+	if el, ok := c.entries[key]; ok {
+		el.Value = e
+		c.lru.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(e)
+	if c.size > 0 && c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+}
+
+// Len returns the number of entries in the cache, including the expired ones
+// that were not evicted yet.
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
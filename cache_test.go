@@ -0,0 +1,111 @@
+package smolder_test
+
+import (
+	"fmt"
+	"github.com/DusanKasan/smolder"
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheEviction(t *testing.T) {
+	c := smolder.NewMemoryCache(0, 2)
+	c.Set(1, "a")
+	c.Set(2, "b")
+	// touch 1, so 2 is the least recently used entry
+	if v, ok := c.Get(1); !ok || v != "a" {
+		t.Fatalf("expected a for 1, got %v", v)
+	}
+	c.Set(3, "c")
+
+	if _, ok := c.Get(2); ok {
+		t.Error("least recently used entry was not evicted")
+	}
+	if _, ok := c.Get(1); !ok {
+		t.Error("recently used entry was evicted")
+	}
+	if c.Len() != 2 {
+		t.Errorf("expected 2 entries, got %v", c.Len())
+	}
+}
+
+func TestMemoryCacheTTL(t *testing.T) {
+	c := smolder.NewMemoryCache(10*time.Millisecond, 0)
+	c.Set(1, "a")
+	if _, ok := c.Get(1); !ok {
+		t.Fatal("entry expired too early")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := c.Get(1); ok {
+		t.Error("entry did not expire")
+	}
+}
+
+func TestRegisterWithCache(t *testing.T) {
+	var countryCalls [][]int64
+
+	loader := smolder.New()
+	if err := loader.Register(loadAddress); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(func(ids []int64) map[int64]*Country {
+		countryCalls = append(countryCalls, ids)
+		return loadCountries(ids)
+	}, smolder.WithCache(smolder.NewMemoryCache(time.Minute, 100))); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ids := range [][]int64{{1, 3}, {2, 3, 5}} {
+		var addresses []Address
+		if err := loader.Load(ids, &addresses); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, a := range addresses {
+			if a.Country.ID == 0 {
+				t.Errorf("country of address %v not loaded", a.ID)
+			}
+		}
+	}
+
+	// countries 1 and 2 are cached by the first load
+	if fmt.Sprint(countryCalls) != "[[1 2] [3]]" {
+		t.Errorf("unexpected country resolver calls: %v", countryCalls)
+	}
+}
+
+func TestSharedCache(t *testing.T) {
+	cache := smolder.NewMemoryCache(time.Minute, 100)
+	loader := smolder.New()
+	if err := loader.Register(loadCountries, smolder.WithCache(cache)); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(func(ids []int64) map[int64]*Role {
+		roles := map[int64]*Role{}
+		for _, id := range ids {
+			roles[id] = &Role{Name: fmt.Sprint("role ", id)}
+		}
+		return roles
+	}, smolder.WithCache(cache)); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		var countries []Country
+		if err := loader.Load([]int64{1}, &countries); err != nil {
+			t.Fatal(err)
+		}
+		var roles []Role
+		if err := loader.Load([]int64{1}, &roles); err != nil {
+			t.Fatal(err)
+		}
+
+		if countries[0].ID != 1 || roles[0].Name != "role 1" {
+			t.Errorf("unexpected values: %+v, %+v", countries, roles)
+		}
+	}
+
+	if cache.Len() != 2 {
+		t.Errorf("expected an entry per resolver, got %v", cache.Len())
+	}
+}
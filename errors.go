@@ -0,0 +1,302 @@
+package smolder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound matches every NotFoundError using errors.Is.
+	ErrNotFound = errors.New("not found")
+	// ErrAmbiguousResult matches every AmbiguousResultError using errors.Is.
+	ErrAmbiguousResult = errors.New("ambiguous result")
+	// ErrNoResolver matches every NoResolverError using errors.Is.
+	ErrNoResolver = errors.New("no resolver")
+	// ErrResolver matches every ResolverError using errors.Is.
+	ErrResolver = errors.New("resolver failed")
+	// ErrCycle matches every CycleError using errors.Is.
+	ErrCycle = errors.New("cycle detected")
+	// ErrMaxDepth matches every MaxDepthError using errors.Is.
+	ErrMaxDepth = errors.New("maximum depth exceeded")
+	// ErrConflict matches every ConflictError using errors.Is.
+	ErrConflict = errors.New("conflicting resolvers")
+	// ErrUnreachable matches every UnreachableError using errors.Is.
+	ErrUnreachable = errors.New("unreachable resolver")
+	// ErrTimeout matches every TimeoutError using errors.Is.
+	ErrTimeout = errors.New("timeout")
+
+	// the causes of the contexts done by the timeouts
+	errResolverTimeout = errors.New("resolver timeout")
+	errLoadTimeout     = errors.New("load timeout")
+	// returned for the resolver calls abandoned once their context is done
+	errAbandoned = errors.New("resolver call abandoned")
+)
+
+// NotFoundError is returned for the keys a resolver returned no values for.
+type NotFoundError struct {
+	// the name of the resolver, empty for unnamed resolvers
+	Name string
+	// the resolved type
+	Type    reflect.Type
+	KeyType reflect.Type
+	Keys    []interface{}
+}
+
+func (e *NotFoundError) Error() string {
+	if e.Name != "" {
+		return fmt.Sprintf("no items found by resolver %q for %v with keys %v", e.Name, e.Type.String(), e.Keys)
+	}
+	return fmt.Sprintf("no items found for %v with keys %v", e.Type.String(), e.Keys)
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// Adds the keys not yet present in the error.
+func (e *NotFoundError) add(keys []interface{}) {
+	for _, key := range keys {
+		found := false
+		for _, k := range e.Keys {
+			if k == key {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			e.Keys = append(e.Keys, key)
+		}
+	}
+}
+
+// AmbiguousResultError is returned when a resolver returned multiple values
+// for a key loaded into a destination for a single value.
+type AmbiguousResultError struct {
+	// the name of the resolver, empty for unnamed resolvers
+	Name string
+	// the resolved type
+	Type    reflect.Type
+	KeyType reflect.Type
+	Keys    []interface{}
+}
+
+func (e *AmbiguousResultError) Error() string {
+	return fmt.Sprintf("multiple items found by the %v for keys %v", resolverName(e.Name, e.Type, e.KeyType), e.Keys)
+}
+
+func (e *AmbiguousResultError) Is(target error) bool {
+	return target == ErrAmbiguousResult
+}
+
+// NoResolverError is returned when there is no resolver registered for the
+// loaded type and key type.
+type NoResolverError struct {
+	// the name of the resolver, empty for unnamed resolvers
+	Name string
+	// the resolved type
+	Type    reflect.Type
+	KeyType reflect.Type
+}
+
+func (e *NoResolverError) Error() string {
+	if e.Name != "" {
+		return fmt.Sprintf("no resolvers named %q found for %v with key type %v", e.Name, e.Type.String(), e.KeyType.String())
+	}
+	return fmt.Sprintf("no resolvers found for %v with key type %v", e.Type.String(), e.KeyType.String())
+}
+
+func (e *NoResolverError) Is(target error) bool {
+	return target == ErrNoResolver
+}
+
+// TimeoutError is returned when a resolver call exceeded the timeout set by
+// WithTimeout, or when the load exceeded the deadline set by WithLoadTimeout
+// while resolving a batch.
+type TimeoutError struct {
+	// the name of the resolver, empty for unnamed resolvers
+	Name string
+	// the resolved type
+	Type    reflect.Type
+	KeyType reflect.Type
+	// the keys passed to the resolver
+	Keys []interface{}
+	// the exceeded timeout
+	Timeout time.Duration
+	// whether the deadline of the whole load was exceeded
+	Load bool
+	// the error returned by the resolver, nil if the call was abandoned
+	Err error
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Load {
+		return fmt.Sprintf("load timed out after %v while waiting for the %v", e.Timeout, resolverName(e.Name, e.Type, e.KeyType))
+	}
+	return fmt.Sprintf("%v timed out after %v", resolverName(e.Name, e.Type, e.KeyType), e.Timeout)
+}
+
+// Is matches ErrTimeout and context.DeadlineExceeded.
+func (e *TimeoutError) Is(target error) bool {
+	return target == ErrTimeout || target == context.DeadlineExceeded
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// PanicError is the error of a resolver that panicked, wrapped in a
+// ResolverError.
+type PanicError struct {
+	// the value passed to panic
+	Value interface{}
+	// the stack of the resolver at the time of the panic, left out of the
+	// error message
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the value passed to panic if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// ConflictError is returned when an interface is loaded by a key type there
+// are resolvers of multiple types implementing it for.
+type ConflictError struct {
+	// the name of the resolvers, empty for unnamed resolvers
+	Name string
+	// the loaded interface
+	Type    reflect.Type
+	KeyType reflect.Type
+	// the types of the resolvers implementing the interface
+	Types []reflect.Type
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("multiple resolvers of types implementing %v found for key type %v: %v", e.Type.String(), e.KeyType.String(), e.Types)
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// UnreachableError is returned by register.Validate for a resolver no
+// declared load can reach.
+type UnreachableError struct {
+	// the name of the resolver, empty for unnamed resolvers
+	Name string
+	// the resolved type
+	Type    reflect.Type
+	KeyType reflect.Type
+}
+
+func (e *UnreachableError) Error() string {
+	if e.Name != "" {
+		return fmt.Sprintf("resolver %q of %v with key type %v is unreachable", e.Name, e.Type.String(), e.KeyType.String())
+	}
+	return fmt.Sprintf("resolver of %v with key type %v is unreachable", e.Type.String(), e.KeyType.String())
+}
+
+func (e *UnreachableError) Is(target error) bool {
+	return target == ErrUnreachable
+}
+
+// ResolverError wraps the error returned by a resolver.
+type ResolverError struct {
+	// the name of the resolver, empty for unnamed resolvers
+	Name string
+	// the resolved type
+	Type    reflect.Type
+	KeyType reflect.Type
+	// the keys passed to the resolver
+	Keys []interface{}
+	Err  error
+}
+
+func (e *ResolverError) Error() string {
+	return fmt.Sprintf("%v failed: %v", resolverName(e.Name, e.Type, e.KeyType), e.Err)
+}
+
+func (e *ResolverError) Is(target error) bool {
+	return target == ErrResolver
+}
+
+func (e *ResolverError) Unwrap() error {
+	return e.Err
+}
+
+// Step is a resolved value on the path of a nested load.
+type Step struct {
+	// the resolved type
+	Type reflect.Type
+	Key  interface{}
+	// the field of the value the next step was loaded into, empty for the
+	// last step
+	Field string
+}
+
+func (s Step) String() string {
+	if s.Field == "" {
+		return fmt.Sprintf("%v(%v)", s.Type.String(), s.Key)
+	}
+	return fmt.Sprintf("%v(%v).%v", s.Type.String(), s.Key, s.Field)
+}
+
+func path(steps []Step) string {
+	s := make([]string, len(steps))
+	for i, step := range steps {
+		s[i] = step.String()
+	}
+
+	return strings.Join(s, " -> ")
+}
+
+// CycleError is returned when a nested load loads a value that is already on
+// its path.
+type CycleError struct {
+	// the path from the first occurrence of the value to the repeated one
+	Path []Step
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("cycle detected: %v", path(e.Path))
+}
+
+func (e *CycleError) Is(target error) bool {
+	return target == ErrCycle
+}
+
+// MaxDepthError is returned when a nested load exceeds the maximum depth.
+type MaxDepthError struct {
+	Depth int
+	// the path to the value the load was issued for
+	Path []Step
+}
+
+func (e *MaxDepthError) Error() string {
+	if len(e.Path) == 0 {
+		return fmt.Sprintf("maximum depth %d exceeded", e.Depth)
+	}
+	return fmt.Sprintf("maximum depth %d exceeded by a load into %v", e.Depth, path(e.Path))
+}
+
+func (e *MaxDepthError) Is(target error) bool {
+	return target == ErrMaxDepth
+}
+
+// Describes the resolver in the error messages.
+func resolverName(name string, typ reflect.Type, keyType reflect.Type) string {
+	if name != "" {
+		return fmt.Sprintf("resolver %q of %v with key type %v", name, typ.String(), keyType.String())
+	}
+	return fmt.Sprintf("resolver of %v with key type %v", typ.String(), keyType.String())
+}
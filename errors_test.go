@@ -0,0 +1,185 @@
+package smolder_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/DusanKasan/smolder"
+	"reflect"
+	"testing"
+)
+
+func TestNoResolverError(t *testing.T) {
+	loader := smolder.New()
+	if err := loader.Register(loadAddress); err != nil {
+		t.Fatal(err)
+	}
+
+	var addresses []Address
+	err := loader.Load([]int64{1}, &addresses)
+	if !errors.Is(err, smolder.ErrNoResolver) {
+		t.Fatalf("expected no resolver error, got %v", err)
+	}
+
+	var nr *smolder.NoResolverError
+	if !errors.As(err, &nr) || nr.Type != reflect.TypeOf(Country{}) || nr.KeyType != reflect.TypeOf(int64(0)) {
+		t.Errorf("unexpected no resolver error: %#v", err)
+	}
+}
+
+func TestResolverError(t *testing.T) {
+	failure := errors.New("db is down")
+
+	loader := smolder.New()
+	if err := loader.Register(loadAddress); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(func(ids []int64) (map[int64]*Country, error) {
+		return nil, failure
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var addresses []Address
+	err := loader.Load([]int64{1, 2, 3}, &addresses)
+	if !errors.Is(err, failure) || !errors.Is(err, smolder.ErrResolver) {
+		t.Fatalf("expected the resolver error, got %v", err)
+	}
+
+	var re *smolder.ResolverError
+	if !errors.As(err, &re) || re.Type != reflect.TypeOf(Country{}) || fmt.Sprint(re.Keys) != "[1 2]" {
+		t.Errorf("unexpected resolver error: %#v", err)
+	}
+}
+
+func TestAmbiguousResultError(t *testing.T) {
+	loader := smolder.New()
+	if err := loader.Register(loadRoles); err != nil {
+		t.Fatal(err)
+	}
+
+	var role Role
+	err := loader.Load(UserId{1}, &role)
+	if !errors.Is(err, smolder.ErrAmbiguousResult) {
+		t.Fatalf("expected ambiguous result error, got %v", err)
+	}
+
+	var ae *smolder.AmbiguousResultError
+	if !errors.As(err, &ae) || fmt.Sprint(ae.Keys) != "[{1}]" {
+		t.Errorf("unexpected ambiguous result error: %#v", err)
+	}
+}
+
+func TestNotFoundError(t *testing.T) {
+	loader := smolder.New()
+	if err := loader.Register(loadCountries); err != nil {
+		t.Fatal(err)
+	}
+
+	var country Country
+	err := loader.Load(int64(42), &country)
+	if !errors.Is(err, smolder.ErrNotFound) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+
+	var nf *smolder.NotFoundError
+	if !errors.As(err, &nf) || fmt.Sprint(nf.Keys) != "[42]" {
+		t.Errorf("unexpected not found error: %#v", err)
+	}
+}
+
+func TestPanicError(t *testing.T) {
+	cause := errors.New("broken")
+	loader := smolder.New()
+	if err := loader.Register(func(ids []int64) map[int64]*Country {
+		panic(cause)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var countries []Country
+	err := loader.Load([]int64{1}, &countries)
+	var resolverErr *smolder.ResolverError
+	if !errors.As(err, &resolverErr) || resolverErr.Type != reflect.TypeOf(Country{}) {
+		t.Fatalf("expected resolver error, got %v", err)
+	}
+
+	var panicErr *smolder.PanicError
+	if !errors.As(err, &panicErr) || panicErr.Value != cause || len(panicErr.Stack) == 0 {
+		t.Errorf("expected panic error, got %v", err)
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("expected the panic error to wrap the panic value")
+	}
+}
+
+func TestRepanic(t *testing.T) {
+	loader := smolder.New(smolder.WithRepanic())
+	if err := loader.Register(func(ids []int64) map[int64]*Country {
+		panic("broken")
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		if v := recover(); v != "broken" {
+			t.Errorf("expected the panic to be propagated, got %v", v)
+		}
+	}()
+
+	var countries []Country
+	loader.Load([]int64{1}, &countries)
+	t.Error("expected a panic")
+}
+
+func TestPanicErrorMessage(t *testing.T) {
+	err := &smolder.PanicError{Value: "broken", Stack: []byte("goroutine 1 [running]:\n...")}
+	if err.Error() != "panic: broken" {
+		t.Errorf("unexpected message: %q", err.Error())
+	}
+}
+
+func TestNamedResolverErrors(t *testing.T) {
+	loader := smolder.New()
+	if err := loader.RegisterNamed("billing", func(ids []int64) (map[int64]*Address, error) {
+		return nil, errors.New("unavailable")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.RegisterNamed("shipping", func(ids []int64) map[int64]*Address {
+		return map[int64]*Address{}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var addresses []Address
+	err := loader.LoadNamed("billing", []int64{1}, &addresses)
+	var resolverErr *smolder.ResolverError
+	if !errors.As(err, &resolverErr) || resolverErr.Name != "billing" {
+		t.Errorf("expected the error of the billing resolver, got %v", err)
+	}
+
+	err = loader.LoadNamed("shipping", []int64{1}, &addresses)
+	var notFound *smolder.NotFoundError
+	if !errors.As(err, &notFound) || notFound.Name != "shipping" {
+		t.Errorf("expected the shipping addresses not to be found, got %v", err)
+	}
+}
+
+func TestNamedAmbiguousResultError(t *testing.T) {
+	loader := smolder.New()
+	if err := loader.RegisterNamed("billing", func(ids []int64) map[int64][]*Address {
+		return map[int64][]*Address{1: {{ID: 1}, {ID: 2}}}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var address Address
+	err := loader.LoadNamed("billing", int64(1), &address)
+	var ambiguous *smolder.AmbiguousResultError
+	if !errors.As(err, &ambiguous) || ambiguous.Name != "billing" {
+		t.Fatalf("expected ambiguous result error of the billing resolver, got %v", err)
+	}
+	if expected := `multiple items found by the resolver "billing" of smolder_test.Address with key type int64 for keys [1]`; err.Error() != expected {
+		t.Errorf("unexpected message: %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package smolder
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Plan is a resolver batch of an explained load along with the batches of the
+// loads its resolver issued.
+type Plan struct {
+	// the name of the resolver, empty for unnamed resolvers
+	Name string
+	// the resolved type
+	Type    reflect.Type
+	KeyType reflect.Type
+	// the keys passed to the resolver
+	Keys []interface{}
+	// the depth of the loads of the batch, 0 for the root load
+	Depth int
+	// the value and its field the first load of the batch was loaded into,
+	// nil for the root batch and for loads not into a resolved value
+	Cause *Step
+	// the time spent resolving the batch
+	Duration time.Duration
+	Children []*Plan
+}
+
+// String prints the plan as an indented tree, one batch per line.
+func (p *Plan) String() string {
+	var b strings.Builder
+	p.print(&b, 0)
+	return b.String()
+}
+
+func (p *Plan) print(b *strings.Builder, indent int) {
+	b.WriteString(strings.Repeat("  ", indent))
+	b.WriteString(p.Type.String())
+	if p.Name != "" {
+		fmt.Fprintf(b, " %q", p.Name)
+	}
+	fmt.Fprintf(b, " by %v %v", p.KeyType.String(), p.Keys)
+	if p.Cause != nil {
+		fmt.Fprintf(b, " for %v", p.Cause)
+	}
+	fmt.Fprintf(b, " (depth %d, %v)\n", p.Depth, p.Duration)
+
+	for _, c := range p.Children {
+		c.print(b, indent+1)
+	}
+}
+
+// Explain loads the ids into dst like Load and returns the plan of the load,
+// the tree of the resolver batches it ran. The plan is returned even if the
+// load fails, containing the batches run until then. It is nil if no batch
+// ran, e.g. if all the keys were cached.
+func (l *register) Explain(ids interface{}, dst interface{}) (*Plan, error) {
+	return l.ExplainContext(context.Background(), ids, dst)
+}
+
+// ExplainContext loads the ids into dst like LoadContext and returns the plan
+// of the load like Explain.
+func (l *register) ExplainContext(ctx context.Context, ids interface{}, dst interface{}) (*Plan, error) {
+	root, err := rootInvocation("", ids, dst)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &session{register: l, memo: memo{}}
+	err = s.resolve(ctx, root)
+
+	var plan *Plan
+	plans := map[*batch]*Plan{}
+	for _, b := range s.batches {
+		p := &Plan{
+			Name:     b.resolver.name,
+			Type:     b.resolver.typ.Elem(),
+			KeyType:  b.resolver.keyType,
+			Keys:     keys(b.ids),
+			Depth:    b.depth,
+			Duration: b.duration,
+		}
+		plans[b] = p
+
+		parent := b.cause.parent
+		if parent == nil {
+			plan = p
+			continue
+		}
+
+		if inv := b.cause; inv.owner != nil {
+			p.Cause = &Step{Type: parent.resolver.typ.Elem(), Key: inv.owner.key, Field: inv.field}
+		}
+		plans[parent].Children = append(plans[parent].Children, p)
+	}
+
+	return plan, err
+}
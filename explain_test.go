@@ -0,0 +1,69 @@
+package smolder_test
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/DusanKasan/smolder"
+)
+
+func TestExplain(t *testing.T) {
+	loader := smolder.New()
+	if err := loader.Register(loadUsers); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadAddress); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadCountries); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadRoles); err != nil {
+		t.Fatal(err)
+	}
+
+	var users []Uuser
+	plan, err := loader.Explain([]int64{1, 2}, &users)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(users) != 2 {
+		t.Errorf("expected the users to be loaded, got %+v", users)
+	}
+
+	// the durations differ from run to run
+	text := regexp.MustCompile(`, [0-9.]+[^)]*s\)`).ReplaceAllString(plan.String(), ")")
+	expected := `smolder_test.Uuser by int64 [1 2] (depth 0)
+  smolder_test.Address by int64 [1 2] for smolder_test.Uuser(1).Addresses (depth 1)
+    smolder_test.Country by int64 [1] for smolder_test.Address(1).Country (depth 2)
+  smolder_test.Role by smolder_test.UserId [{1} {2}] for smolder_test.Uuser(1).Roles (depth 1)
+`
+	if text != expected {
+		t.Errorf("unexpected plan:\n%v", text)
+	}
+}
+
+func TestExplainFailedLoad(t *testing.T) {
+	loader := smolder.New()
+	if err := loader.Register(loadAddress); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(func(ids []int64) (map[int64]*Country, error) {
+		return nil, errors.New("unavailable")
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var addresses []Address
+	plan, err := loader.Explain([]int64{1}, &addresses)
+	if !errors.Is(err, smolder.ErrResolver) {
+		t.Fatalf("expected resolver error, got %v", err)
+	}
+
+	if plan == nil || len(plan.Children) != 1 || plan.Children[0].Type != reflect.TypeOf(Country{}) {
+		t.Errorf("expected the failed batch in the plan, got:\n%v", plan)
+	}
+}
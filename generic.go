@@ -0,0 +1,50 @@
+package smolder
+
+import "context"
+
+// RegisterFunc registers a resolver of T by keys of type K. It is the
+// type-safe variant of register.Register, so signature mistakes are caught by
+// the compiler instead of being reported at runtime.
+func RegisterFunc[K comparable, T any](r *register, fn func(context.Context, Loader, []K) (map[K]*T, error), opts ...RegisterOption) error {
+	return r.Register(fn, opts...)
+}
+
+// RegisterNamedFunc registers a resolver of T by keys of type K under the
+// name. It is the type-safe variant of register.RegisterNamed.
+func RegisterNamedFunc[K comparable, T any](r *register, name string, fn func(context.Context, Loader, []K) (map[K]*T, error), opts ...RegisterOption) error {
+	return r.RegisterNamed(name, fn, opts...)
+}
+
+// RegisterManyFunc registers a resolver returning multiple T for each key of
+// type K. It is the type-safe variant of register.Register.
+func RegisterManyFunc[K comparable, T any](r *register, fn func(context.Context, Loader, []K) (map[K][]*T, error), opts ...RegisterOption) error {
+	return r.Register(fn, opts...)
+}
+
+// RegisterNamedManyFunc registers a resolver returning multiple T for each key
+// of type K under the name. It is the type-safe variant of
+// register.RegisterNamed.
+func RegisterNamedManyFunc[K comparable, T any](r *register, name string, fn func(context.Context, Loader, []K) (map[K][]*T, error), opts ...RegisterOption) error {
+	return r.RegisterNamed(name, fn, opts...)
+}
+
+// LoadMany loads the T for all the ids, using the resolvers of the register.
+func LoadMany[K comparable, T any](ctx context.Context, r *register, ids []K) ([]T, error) {
+	var dst []T
+	if err := r.LoadContext(ctx, ids, &dst); err != nil {
+		return nil, err
+	}
+
+	return dst, nil
+}
+
+// LoadOne loads the T for the id, using the resolvers of the register.
+func LoadOne[K comparable, T any](ctx context.Context, r *register, id K) (T, error) {
+	var dst T
+	if err := r.LoadContext(ctx, id, &dst); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return dst, nil
+}
@@ -0,0 +1,78 @@
+package smolder_test
+
+import (
+	"context"
+	"github.com/DusanKasan/smolder"
+	"testing"
+)
+
+func TestGenericAPI(t *testing.T) {
+	loader := smolder.New()
+	if err := smolder.RegisterFunc(loader, func(ctx context.Context, l smolder.Loader, ids []int64) (map[int64]*Uuser, error) {
+		return loadUsers(l, ids), nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := smolder.RegisterFunc(loader, func(ctx context.Context, l smolder.Loader, ids []int64) (map[int64]*Address, error) {
+		return loadAddress(l, ids), nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := smolder.RegisterManyFunc(loader, func(ctx context.Context, l smolder.Loader, users []UserId) (map[UserId][]*Role, error) {
+		return loadRoles(users), nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// the reflection based registration interoperates with the generic one
+	if err := loader.Register(loadCountries); err != nil {
+		t.Fatal(err)
+	}
+
+	users, err := smolder.LoadMany[int64, Uuser](context.Background(), loader, []int64{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("expected 2 users, got %v", len(users))
+	}
+
+	address, err := smolder.LoadOne[int64, Address](context.Background(), loader, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address.ID != 3 || address.Country.ID != 2 {
+		t.Errorf("unexpected address: %+v", address)
+	}
+}
+
+func TestGenericOptions(t *testing.T) {
+	loader := smolder.New()
+	var calls [][]int64
+	if err := smolder.RegisterNamedFunc(loader, "capped", func(ctx context.Context, l smolder.Loader, ids []int64) (map[int64]*Country, error) {
+		calls = append(calls, ids)
+		return loadCountries(ids), nil
+	}, smolder.WithMaxBatchSize(1), smolder.WithMissingPolicy(smolder.MissingSkip)); err != nil {
+		t.Fatal(err)
+	}
+	if err := smolder.RegisterNamedManyFunc(loader, "many", func(ctx context.Context, l smolder.Loader, users []UserId) (map[UserId][]*Role, error) {
+		return loadRoles(users), nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var countries []Country
+	if err := loader.LoadNamed("capped", []int64{1, 2, 99}, &countries); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 3 || len(countries) != 2 {
+		t.Errorf("expected the options to apply, got calls %v and countries %+v", calls, countries)
+	}
+
+	var roles []Role
+	if err := loader.LoadNamed("many", UserId{1}, &roles); err != nil {
+		t.Fatal(err)
+	}
+	if len(roles) == 0 {
+		t.Errorf("expected the roles to be loaded by the named resolver")
+	}
+}
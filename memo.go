@@ -0,0 +1,82 @@
+package smolder
+
+import "reflect"
+
+// memo is the request-scoped identity cache of a single load. It maps the
+// resolver and the key to the resolved values, so every key is resolved at
+// most once per load.
+type memo map[*resolver]map[interface{}]memoEntry
+
+type memoEntry struct {
+	// reflection of []*T resolved for the key
+	values reflect.Value
+	// the batch that resolved the values, nil for values from a cache
+	batch *batch
+}
+
+// Returns the selection the values were resolved with, nil for all the
+// fields.
+func (e memoEntry) selection() Selection {
+	if e.batch == nil {
+		return nil
+	}
+
+	return e.batch.selection
+}
+
+func (m memo) get(r *resolver, key interface{}) (memoEntry, bool) {
+	e, ok := m[r][key]
+	return e, ok
+}
+
+func (m memo) add(r *resolver, key interface{}, e memoEntry) {
+	if m[r] == nil {
+		m[r] = map[interface{}]memoEntry{}
+	}
+
+	m[r][key] = e
+}
+
+// Stores all the values resolved by the batch.
+func (m memo) set(b *batch) {
+	for _, key := range b.resolved.MapKeys() {
+		m.add(b.resolver, key.Interface(), memoEntry{b.resolved.MapIndex(key), b})
+	}
+}
+
+// MemoStats counts the keys of one type that were taken from the memo of a
+// load (hits) and the keys that were not in the memo (misses).
+type MemoStats struct {
+	Hits   int
+	Misses int
+}
+
+// MemoStats returns the memo hits and misses of all the loads so far, by the
+// resolved type.
+func (l *register) MemoStats() map[reflect.Type]MemoStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := map[reflect.Type]MemoStats{}
+	for typ, s := range l.memoStats {
+		stats[typ] = *s
+	}
+
+	return stats
+}
+
+func (l *register) count(typ reflect.Type, hits int, misses int) {
+	if hits == 0 && misses == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	typ = typ.Elem()
+	if l.memoStats[typ] == nil {
+		l.memoStats[typ] = &MemoStats{}
+	}
+	l.memoStats[typ].Hits += hits
+	l.memoStats[typ].Misses += misses
+}
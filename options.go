@@ -0,0 +1,159 @@
+package smolder
+
+import "time"
+
+// Option configures the register created by New.
+type Option func(*register)
+
+// WithConcurrency sets how many independent resolver batches of one wave
+// can run in parallel. By default the batches run one after another, n <= 0
+// removes the limit.
+func WithConcurrency(n int) Option {
+	return func(l *register) {
+		l.concurrency = n
+	}
+}
+
+// RecursionPolicy decides what happens with the nested loads exceeding the
+// maximum depth or loading a value again along their path.
+type RecursionPolicy int
+
+const (
+	// RecursionFail fails the whole load with a MaxDepthError or a
+	// CycleError.
+	RecursionFail RecursionPolicy = iota
+	// RecursionStop stops the expansion of the graph, leaving the keys out
+	// of the destinations. The callbacks of a load left out entirely are not
+	// called.
+	RecursionStop
+)
+
+// WithMaxDepth limits the depth of the nested loads, the root load having
+// depth 0. The loads deeper than n are handled by the policy p. By default
+// the depth is unlimited.
+func WithMaxDepth(n int, p RecursionPolicy) Option {
+	return func(l *register) {
+		l.maxDepth = n
+		l.depthPolicy = p
+	}
+}
+
+// WithCycleDetection detects the nested loads of a key of a resolver that is
+// already on their path, e.g. a friend of a friend being the user the load
+// started with. The keys are handled by the policy p. The path is followed
+// through the fields of the resolved values the loads are loaded into.
+func WithCycleDetection(p RecursionPolicy) Option {
+	return func(l *register) {
+		l.cycles = true
+		l.cyclePolicy = p
+	}
+}
+
+// WithRoots declares the loads started by the users of the register, e.g. by
+// Load. register.Validate reports the resolvers these loads can't reach.
+func WithRoots(rels ...Relation) Option {
+	return func(l *register) {
+		l.roots = append(l.roots, rels...)
+	}
+}
+
+// WithTracer traces every resolver batch of the loads by t.
+func WithTracer(t Tracer) Option {
+	return func(l *register) {
+		l.tracer = t
+	}
+}
+
+// WithRepanic propagates the panics of the resolvers to the goroutine calling
+// them instead of returning a ResolverError wrapping a PanicError. It is meant
+// for the development, to get the panics in the debugger. Resolvers run in
+// parallel by WithConcurrency or WithChunkConcurrency panic in their own
+// goroutines.
+func WithRepanic() Option {
+	return func(l *register) {
+		l.repanic = true
+	}
+}
+
+// WithLoadTimeout sets the deadline of every load to d after it started. A
+// load exceeding it fails with a TimeoutError naming the resolver it was
+// waiting for.
+func WithLoadTimeout(d time.Duration) Option {
+	return func(l *register) {
+		l.loadTimeout = d
+	}
+}
+
+// RegisterOption configures a single resolver passed to register.Register.
+type RegisterOption func(*resolver)
+
+// WithCache makes the resolved values of the resolver survive between loads
+// in the cache c. Only the keys missing from the cache are resolved.
+func WithCache(c Cache) RegisterOption {
+	return func(r *resolver) {
+		r.cache = c
+	}
+}
+
+// MissingPolicy decides what happens with the keys a resolver returned no
+// values for.
+type MissingPolicy int
+
+const (
+	// MissingFail fails the whole load with a NotFoundError.
+	MissingFail MissingPolicy = iota
+	// MissingSkip silently leaves the keys out of the destinations.
+	MissingSkip
+	// MissingZero puts the zero value (nil for pointers) into the
+	// destinations for each of the keys.
+	MissingZero
+	// MissingRecord leaves the keys out of the destinations like MissingSkip,
+	// but the load returns a NotFoundError for them once it completes.
+	MissingRecord
+)
+
+// WithMissingPolicy sets the policy for the keys the resolver returned no
+// values for. The default is MissingFail.
+func WithMissingPolicy(p MissingPolicy) RegisterOption {
+	return func(r *resolver) {
+		r.missing = p
+	}
+}
+
+// WithMaxBatchSize limits the number of ids passed to one call of the
+// resolver. Bigger batches are split into chunks of at most n ids, resolved
+// by separate calls and merged.
+func WithMaxBatchSize(n int) RegisterOption {
+	return func(r *resolver) {
+		r.maxBatchSize = n
+	}
+}
+
+// WithChunkConcurrency sets how many chunks of a batch split by
+// WithMaxBatchSize can be resolved in parallel. By default the chunks are
+// resolved one after another, n <= 0 removes the limit.
+func WithChunkConcurrency(n int) RegisterOption {
+	return func(r *resolver) {
+		r.chunkConcurrency = n
+	}
+}
+
+// WithRelations declares the loads the resolver issues that are not declared
+// by the tags of the resolved type, e.g. the loads into local variables or the
+// tagged relations loaded by Loader.LoadFrom. They are checked by
+// register.Validate.
+func WithRelations(rels ...Relation) RegisterOption {
+	return func(r *resolver) {
+		r.relations = append(r.relations, rels...)
+	}
+}
+
+// WithTimeout limits each call of the resolver to d. The resolver gets a
+// context done once d passes, a call exceeding it fails the load with a
+// TimeoutError. A resolver ignoring the context is abandoned, so it doesn't
+// block the load.
+func WithTimeout(d time.Duration) RegisterOption {
+	return func(r *resolver) {
+		r.timeout = d
+	}
+}
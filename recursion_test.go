@@ -0,0 +1,121 @@
+package smolder_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DusanKasan/smolder"
+)
+
+type Friend struct {
+	ID      int64
+	Friends []Friend
+}
+
+// 1 -> 2 -> 1, 3
+var friendships = map[int64][]int64{1: {2}, 2: {1, 3}, 3: {}}
+
+func registerFriends(t *testing.T, opts ...smolder.Option) interface {
+	Load(ids interface{}, dst interface{}) error
+} {
+	loader := smolder.New(opts...)
+	if err := loader.Register(func(l smolder.Loader, ids []int64) map[int64]*Friend {
+		friends := map[int64]*Friend{}
+		for _, id := range ids {
+			f := &Friend{ID: id}
+			l.Load(friendships[id], &f.Friends)
+			friends[id] = f
+		}
+		return friends
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	return loader
+}
+
+func TestCycleFail(t *testing.T) {
+	loader := registerFriends(t, smolder.WithCycleDetection(smolder.RecursionFail))
+
+	var friend Friend
+	err := loader.Load(int64(1), &friend)
+	if !errors.Is(err, smolder.ErrCycle) {
+		t.Fatalf("expected a cycle error, got %v", err)
+	}
+	if expected := "cycle detected: smolder_test.Friend(1).Friends -> smolder_test.Friend(2).Friends -> smolder_test.Friend(1)"; err.Error() != expected {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCycleStop(t *testing.T) {
+	loader := registerFriends(t, smolder.WithCycleDetection(smolder.RecursionStop))
+
+	var friend Friend
+	if err := loader.Load(int64(1), &friend); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(friend.Friends) != 1 || friend.Friends[0].ID != 2 {
+		t.Fatalf("unexpected friends: %+v", friend.Friends)
+	}
+	if friends := friend.Friends[0].Friends; len(friends) != 1 || friends[0].ID != 3 {
+		t.Errorf("unexpected friends of the friend: %+v", friends)
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	loader := registerFriends(t, smolder.WithMaxDepth(1, smolder.RecursionStop))
+
+	var friend Friend
+	if err := loader.Load(int64(1), &friend); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(friend.Friends) != 1 || friend.Friends[0].ID != 2 {
+		t.Fatalf("unexpected friends: %+v", friend.Friends)
+	}
+	if friend.Friends[0].Friends != nil {
+		t.Errorf("expected the friends of the friend not to be loaded, got %+v", friend.Friends[0].Friends)
+	}
+
+	loader = registerFriends(t, smolder.WithMaxDepth(1, smolder.RecursionFail))
+	err := loader.Load(int64(1), &friend)
+	var depthErr *smolder.MaxDepthError
+	if !errors.As(err, &depthErr) || depthErr.Depth != 1 || len(depthErr.Path) != 2 {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCutValuesNotCached(t *testing.T) {
+	for _, opt := range []smolder.Option{
+		smolder.WithMaxDepth(1, smolder.RecursionStop),
+		smolder.WithCycleDetection(smolder.RecursionStop),
+	} {
+		loader := smolder.New(opt)
+		if err := loader.Register(func(l smolder.Loader, ids []int64) map[int64]*Friend {
+			friends := map[int64]*Friend{}
+			for _, id := range ids {
+				f := &Friend{ID: id}
+				l.Load(friendships[id], &f.Friends)
+				friends[id] = f
+			}
+			return friends
+		}, smolder.WithCache(smolder.NewMemoryCache(time.Minute, 100))); err != nil {
+			t.Fatal(err)
+		}
+
+		var friend Friend
+		if err := loader.Load(int64(1), &friend); err != nil {
+			t.Fatal(err)
+		}
+
+		// friend 2 was cut short while loading friend 1
+		if err := loader.Load(int64(2), &friend); err != nil {
+			t.Fatal(err)
+		}
+		if len(friend.Friends) != 2 || friend.Friends[1].ID != 3 {
+			t.Errorf("expected the friends of friend 2 to be loaded, got %+v", friend.Friends)
+		}
+	}
+}
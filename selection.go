@@ -0,0 +1,130 @@
+package smolder
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// Selection is the set of fields requested from a load, each of them with the
+// selection of its nested fields. A nil Selection selects all the fields.
+//
+// The selection of a load is passed to the resolvers in their context, so they
+// can fetch only the selected fields and skip the relations not asked for. The
+// nested loads get the selection of the field they are loaded into.
+type Selection map[string]Selection
+
+type selectionKey struct{}
+
+// Select creates a Selection from the paths of the fields, with the nested
+// fields separated by dots, e.g. Select("Name", "Campaigns.Flights.Name"). A
+// field selected without nested fields selects all of its nested fields.
+func Select(paths ...string) Selection {
+	s := Selection{}
+	for _, path := range paths {
+		current := s
+		for _, field := range strings.Split(path, ".") {
+			if current[field] == nil {
+				current[field] = Selection{}
+			}
+			current = current[field]
+		}
+	}
+
+	return s.normalize()
+}
+
+// Replaces the empty nested selections by nil, selecting all the fields.
+func (s Selection) normalize() Selection {
+	for field, sub := range s {
+		if len(sub) == 0 {
+			s[field] = nil
+		} else {
+			sub.normalize()
+		}
+	}
+
+	return s
+}
+
+// Has returns whether the field is selected.
+func (s Selection) Has(field string) bool {
+	if s == nil {
+		return true
+	}
+
+	_, ok := s[field]
+	return ok
+}
+
+// Sub returns the selection of the nested fields of the field.
+func (s Selection) Sub(field string) Selection {
+	return s[field]
+}
+
+// Fields returns the sorted names of the selected fields, nil if all the
+// fields are selected.
+func (s Selection) Fields() []string {
+	if s == nil {
+		return nil
+	}
+
+	fields := make([]string, 0, len(s))
+	for field := range s {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	return fields
+}
+
+// Returns the union of the selections.
+func merge(a Selection, b Selection) Selection {
+	if a == nil || b == nil {
+		return nil
+	}
+
+	m := Selection{}
+	for field, sub := range a {
+		m[field] = sub
+	}
+	for field, sub := range b {
+		if existing, ok := m[field]; ok {
+			m[field] = merge(existing, sub)
+		} else {
+			m[field] = sub
+		}
+	}
+
+	return m
+}
+
+// Returns whether all the fields selected by b are selected by a.
+func covers(a Selection, b Selection) bool {
+	if a == nil {
+		return true
+	}
+	if b == nil {
+		return false
+	}
+
+	for field, sub := range b {
+		if existing, ok := a[field]; !ok || !covers(existing, sub) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// WithSelection returns a copy of ctx carrying the selection. Loading with
+// the returned context passes the selection to the resolvers.
+func WithSelection(ctx context.Context, s Selection) context.Context {
+	return context.WithValue(ctx, selectionKey{}, s)
+}
+
+// SelectionFrom returns the selection carried by ctx, nil if there is none.
+func SelectionFrom(ctx context.Context) Selection {
+	s, _ := ctx.Value(selectionKey{}).(Selection)
+	return s
+}
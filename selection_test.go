@@ -0,0 +1,219 @@
+package smolder_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/DusanKasan/smolder"
+)
+
+func TestSelect(t *testing.T) {
+	s := smolder.Select("Name", "Addresses.Country.Name", "Addresses.ID")
+
+	if !reflect.DeepEqual(s.Fields(), []string{"Addresses", "Name"}) {
+		t.Errorf("unexpected fields: %v", s.Fields())
+	}
+	if !s.Has("Name") || s.Has("Roles") {
+		t.Errorf("unexpected selected fields: %v", s)
+	}
+	if s.Sub("Name") != nil {
+		t.Errorf("expected all the nested fields of a field without a path to be selected, got %v", s.Sub("Name"))
+	}
+	if !reflect.DeepEqual(s.Sub("Addresses").Sub("Country").Fields(), []string{"Name"}) {
+		t.Errorf("unexpected nested selection: %v", s.Sub("Addresses"))
+	}
+
+	var all smolder.Selection
+	if !all.Has("Roles") || all.Fields() != nil {
+		t.Errorf("expected a nil selection to select all the fields")
+	}
+}
+
+func TestSelection(t *testing.T) {
+	var userFields, addressFields []string
+	loader := smolder.New()
+	if err := loader.Register(func(ctx context.Context, l smolder.Loader, ids []int64) map[int64]*TaggedUser {
+		userFields = smolder.SelectionFrom(ctx).Fields()
+		users := map[int64]*TaggedUser{}
+		for _, u := range db.Users {
+			for _, id := range ids {
+				if u.ID == id {
+					usr := &TaggedUser{ID: u.ID, AddressIDs: u.AddressIDs}
+					l.LoadFrom(struct{ UserID UserId }{UserId{u.ID}}, usr)
+					users[id] = usr
+				}
+			}
+		}
+		return users
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(func(ctx context.Context, ids []int64) map[int64]*TaggedAddress {
+		addressFields = smolder.SelectionFrom(ctx).Fields()
+		addresses := map[int64]*TaggedAddress{}
+		for _, a := range db.Addresses {
+			for _, id := range ids {
+				if a.ID == id {
+					countryID := a.CountryID
+					addresses[id] = &TaggedAddress{ID: a.ID, CountryID: &countryID}
+				}
+			}
+		}
+		return addresses
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadCountries); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(func(ids []UserId) map[UserId][]*Role {
+		t.Error("unexpected load of the roles")
+		return loadRoles(ids)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := smolder.WithSelection(context.Background(), smolder.Select("ID", "Addresses.ID", "Addresses.Country"))
+	var users []TaggedUser
+	if err := loader.LoadContext(ctx, []int64{1}, &users); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(userFields, []string{"Addresses", "ID"}) {
+		t.Errorf("unexpected selection of the users: %v", userFields)
+	}
+	if !reflect.DeepEqual(addressFields, []string{"Country", "ID"}) {
+		t.Errorf("unexpected selection of the addresses: %v", addressFields)
+	}
+	if len(users[0].Addresses) != 2 || users[0].Addresses[0].Country == nil {
+		t.Errorf("unexpected addresses: %+v", users[0].Addresses)
+	}
+	if users[0].Roles != nil {
+		t.Errorf("expected the roles not to be loaded, got %+v", users[0].Roles)
+	}
+}
+
+type (
+	SCountry struct {
+		ID   int64
+		Name string
+		Code string
+	}
+	SCompany struct {
+		ID      int64
+		Country SCountry
+	}
+	SPerson struct {
+		ID          int64
+		Nationality SCountry
+		Residence   SCountry
+		Employer    SCompany
+	}
+)
+
+// Registers the resolvers of SPerson loading the countries with the selected
+// fields only and returns the selections the countries were resolved with.
+func registerSelectedPeople(t *testing.T, loader interface {
+	Register(fn interface{}, opts ...smolder.RegisterOption) error
+}) *[][]string {
+	var selections [][]string
+	if err := loader.Register(func(ctx context.Context, l smolder.Loader, ids []int64) map[int64]*SPerson {
+		s := smolder.SelectionFrom(ctx)
+		people := map[int64]*SPerson{}
+		for _, id := range ids {
+			p := &SPerson{ID: id}
+			if s.Has("Nationality") {
+				l.Load(int64(1), &p.Nationality)
+			}
+			if s.Has("Residence") {
+				l.Load(int64(1), &p.Residence)
+			}
+			if s.Has("Employer") {
+				l.Load(int64(2), &p.Employer)
+			}
+			people[id] = p
+		}
+		return people
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(func(l smolder.Loader, ids []int64) map[int64]*SCompany {
+		companies := map[int64]*SCompany{}
+		for _, id := range ids {
+			c := &SCompany{ID: id}
+			l.Load(int64(1), &c.Country)
+			companies[id] = c
+		}
+		return companies
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(func(ctx context.Context, ids []int64) map[int64]*SCountry {
+		s := smolder.SelectionFrom(ctx)
+		selections = append(selections, s.Fields())
+		countries := map[int64]*SCountry{}
+		for _, id := range ids {
+			c := &SCountry{ID: id}
+			if s.Has("Name") {
+				c.Name = "Slovakia"
+			}
+			if s.Has("Code") {
+				c.Code = "SK"
+			}
+			countries[id] = c
+		}
+		return countries
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	return &selections
+}
+
+func TestSelectionOfSameKeyInWave(t *testing.T) {
+	loader := smolder.New()
+	selections := registerSelectedPeople(t, loader)
+
+	ctx := smolder.WithSelection(context.Background(), smolder.Select("Nationality.Name", "Residence.Code"))
+	var person SPerson
+	if err := loader.LoadContext(ctx, int64(1), &person); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(*selections, [][]string{{"Code", "Name"}}) {
+		t.Errorf("unexpected selections of the countries: %v", *selections)
+	}
+	if person.Nationality.Name != "Slovakia" || person.Residence.Code != "SK" {
+		t.Errorf("unexpected countries: %+v, %+v", person.Nationality, person.Residence)
+	}
+}
+
+func TestSelectionOfMemoizedKey(t *testing.T) {
+	loader := smolder.New()
+	selections := registerSelectedPeople(t, loader)
+
+	ctx := smolder.WithSelection(context.Background(), smolder.Select("Nationality.Name", "Employer.Country.Code"))
+	var person SPerson
+	if err := loader.LoadContext(ctx, int64(1), &person); err != nil {
+		t.Fatal(err)
+	}
+
+	// the country memoized with the name only is resolved again with the code
+	if !reflect.DeepEqual(*selections, [][]string{{"Name"}, {"Code", "Name"}}) {
+		t.Errorf("unexpected selections of the countries: %v", *selections)
+	}
+	if person.Nationality.Name != "Slovakia" || person.Employer.Country.Code != "SK" {
+		t.Errorf("unexpected countries: %+v, %+v", person.Nationality, person.Employer.Country)
+	}
+
+	// the country selected as a whole covers any later selection
+	*selections = nil
+	ctx = smolder.WithSelection(context.Background(), smolder.Select("Nationality", "Employer.Country.Code"))
+	if err := loader.LoadContext(ctx, int64(1), &person); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*selections, [][]string{nil}) {
+		t.Errorf("unexpected selections of the countries: %#v", *selections)
+	}
+}
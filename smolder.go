@@ -5,15 +5,43 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
 )
 
 type register struct {
-	resolvers map[reflect.Type]map[reflect.Type]func(context.Context, *loader, interface{}) (interface{}, error)
+	// map of resolver name to map of resolved type to map of key type to the
+	// resolver, unnamed resolvers have an empty name
+	resolvers   map[string]map[reflect.Type]map[reflect.Type]*resolver
+	concurrency int
+	tracer      Tracer
+	// whether the panics of the resolvers are propagated
+	repanic bool
+	// the deadline of a whole load, 0 means no deadline
+	loadTimeout time.Duration
+	// the maximum depth of the nested loads, 0 means no limit
+	maxDepth    int
+	depthPolicy RecursionPolicy
+	// whether the cycles are detected
+	cycles      bool
+	cyclePolicy RecursionPolicy
+	// the loads started by the users of the register
+	roots []Relation
+
+	mu        sync.Mutex
+	memoStats map[reflect.Type]*MemoStats
 }
 
-func New() *register {
-	m := map[reflect.Type]map[reflect.Type]func(context.Context, *loader, interface{}) (interface{}, error){}
-	return &register{resolvers: m}
+func New(opts ...Option) *register {
+	m := map[string]map[reflect.Type]map[reflect.Type]*resolver{}
+	l := &register{resolvers: m, concurrency: 1, memoStats: map[reflect.Type]*MemoStats{}}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
 }
 
 // fn for type T must be one of:
@@ -33,7 +61,16 @@ func New() *register {
 // - func(loader, []K) map[K]*[]T
 // - func(context.Context, smolder.loader, []K) map[K]*T
 // - func(context.Context, smolder.loader, []K) map[K]*[]T
-func (l *register) Register(fn interface{}) error {
+//
+// The map values can also be T instead of *T, e.g. for scalars or interfaces.
+func (l *register) Register(fn interface{}, opts ...RegisterOption) error {
+	return l.RegisterNamed("", fn, opts...)
+}
+
+// RegisterNamed registers fn like Register under the name. Named resolvers
+// can be used for multiple relations resolving the same type by the same key
+// type and are loaded by LoadNamed.
+func (l *register) RegisterNamed(name string, fn interface{}, opts ...RegisterOption) error {
 	var inTransform func(ctx context.Context, loader *loader, ids interface{}) []reflect.Value
 	var outTransform func(vals []reflect.Value) (interface{}, error)
 
@@ -160,253 +197,1203 @@ func (l *register) Register(fn interface{}) error {
 		typ = typ.Elem()
 	}
 
-	m := l.resolvers[typ]
-	if m == nil {
-		l.resolvers[typ] = map[reflect.Type]func(context.Context, *loader, interface{}) (interface{}, error){}
+	// values that are not pointers (e.g. scalars or interfaces) are resolved
+	// as pointers to them
+	pointers := typ.Kind() != reflect.Ptr
+	if pointers {
+		typ = reflect.PtrTo(typ)
 	}
 
-	if _, ok := l.resolvers[typ][keyType]; ok {
+	if l.resolvers[name] == nil {
+		l.resolvers[name] = map[reflect.Type]map[reflect.Type]*resolver{}
+	}
+	if l.resolvers[name][typ] == nil {
+		l.resolvers[name][typ] = map[reflect.Type]*resolver{}
+	}
+
+	if _, ok := l.resolvers[name][typ][keyType]; ok {
+		if name != "" {
+			return fmt.Errorf("resolver %q already registered for %v and key type %v", name, typ.String(), keyType.String())
+		}
 		return fmt.Errorf("resolver already registered for %v and key type %v", typ.String(), keyType.String())
 	}
 
-	l.resolvers[typ][keyType] = func(ctx context.Context, loader *loader, ids interface{}) (interface{}, error) {
+	r := &resolver{name: name, typ: typ, keyType: keyType, many: t.Out(0).Elem().Kind() == reflect.Slice, chunkConcurrency: 1, fn: func(ctx context.Context, loader *loader, ids interface{}) (interface{}, error) {
 		if reflect.TypeOf(ids).Kind() != reflect.Slice || reflect.TypeOf(ids).Elem() != keyType {
 			return nil, fmt.Errorf("invalid ids type, expecting slice of %v, got %v", keyType.String(), reflect.TypeOf(ids).String())
 		}
 
-		return outTransform(reflect.ValueOf(fn).Call(inTransform(ctx, loader, ids)))
+		vals, err := outTransform(reflect.ValueOf(fn).Call(inTransform(ctx, loader, ids)))
+		if pointers {
+			vals = toPointers(vals)
+		}
+
+		return vals, err
+	}}
+	for _, opt := range opts {
+		opt(r)
 	}
+	l.resolvers[name][typ][keyType] = r
 
 	return nil
 }
 
+// Converts map[K][]T to map[K][]*T.
+func toPointers(vals interface{}) interface{} {
+	m := reflect.ValueOf(vals)
+	res := reflect.MakeMapWithSize(reflect.MapOf(m.Type().Key(), reflect.SliceOf(reflect.PtrTo(m.Type().Elem().Elem()))), m.Len())
+	for _, key := range m.MapKeys() {
+		values := m.MapIndex(key)
+		pointers := reflect.MakeSlice(res.Type().Elem(), values.Len(), values.Len())
+		for i := 0; i < values.Len(); i++ {
+			pointers.Index(i).Set(reflect.New(values.Index(i).Type()))
+			pointers.Index(i).Elem().Set(values.Index(i))
+		}
+		res.SetMapIndex(key, pointers)
+	}
+
+	return res.Interface()
+}
+
 func (l *register) Load(ids interface{}, dst interface{}) error {
+	return l.LoadContext(context.Background(), ids, dst)
+}
+
+// LoadContext loads the ids into dst like Load, passing ctx to every resolver
+// on every nested level. No new resolver batches are scheduled once ctx is
+// done, the load then fails with the ctx error.
+func (l *register) LoadContext(ctx context.Context, ids interface{}, dst interface{}) error {
+	return l.LoadNamedContext(ctx, "", ids, dst)
+}
+
+// LoadNamed loads the ids into dst like Load, using the resolver registered
+// by RegisterNamed under the name.
+func (l *register) LoadNamed(name string, ids interface{}, dst interface{}) error {
+	return l.LoadNamedContext(context.Background(), name, ids, dst)
+}
+
+// LoadNamedContext loads the ids into dst like LoadContext, using the resolver
+// registered by RegisterNamed under the name.
+func (l *register) LoadNamedContext(ctx context.Context, name string, ids interface{}, dst interface{}) error {
+	root, err := rootInvocation(name, ids, dst)
+	if err != nil {
+		return err
+	}
+
+	return l.resolve(ctx, root)
+}
+
+// Validates the destination of a load and returns its invocation.
+func rootInvocation(name string, ids interface{}, dst interface{}) (*invocation, error) {
 	typ := reflect.TypeOf(dst)
 	if typ.Kind() != reflect.Ptr {
-		return errors.New("dst must be a pointer to a slice")
+		return nil, errors.New("dst must be a pointer to a slice")
 	}
 	target := typ.Elem()
 
-	switch reflect.TypeOf(ids).Kind() {
-	case reflect.Slice:
-		if target.Kind() != reflect.Slice {
-			return errors.New("dst must be a pointer to slice when loading multiple items")
+	if reflect.TypeOf(ids).Kind() == reflect.Slice && target.Kind() != reflect.Slice && target.Kind() != reflect.Map {
+		return nil, errors.New("dst must be a pointer to slice or map when loading multiple items")
+	}
+
+	return newInvocation(name, ids, dst), nil
+}
+
+type (
+	Loader interface {
+		Load(ids interface{}, dst interface{}) Future
+		// LoadNamed loads the ids using the resolver registered under the
+		// name by register.RegisterNamed.
+		LoadNamed(name string, ids interface{}, dst interface{}) Future
+		// LoadFrom loads the fields of dst, a pointer to a struct, tagged
+		// like `smolder:"from=KeyField,row"`, taking the keys from the fields
+		// of src. It is meant for the keys that are not part of the loaded
+		// type, e.g. the fields of a database row. The load fails if src has
+		// no key field of a relation. Tagged fields without the row flag are
+		// loaded automatically using the keys on the loaded type itself.
+		LoadFrom(src interface{}, dst interface{})
+	}
+
+	// AfterLoader is implemented by the loaded types that need to run code
+	// once all the nested loads of a value completed, e.g. to compute derived
+	// fields. AfterLoad is called bottom-up, after AfterLoad of the nested
+	// values.
+	AfterLoader interface {
+		AfterLoad(ctx context.Context) error
+	}
+
+	// Future is a pending Loader.Load.
+	Future interface {
+		// Then registers fn to be called once the values of the load are
+		// assigned to its destination, before the nested loads of the values
+		// complete. Loads issued by fn are batched with the other loads of
+		// the next depth. The load fails if fn returns an error.
+		Then(fn func() error) Future
+	}
+
+	loader struct {
+		// the batch whose resolver got the loader
+		batch       *batch
+		mu          sync.Mutex
+		invocations []*invocation
+		// the invocations not scheduled yet
+		pending []*invocation
+		err     error
+	}
+)
+
+func (l *loader) Load(ids interface{}, dst interface{}) Future {
+	return l.LoadNamed("", ids, dst)
+}
+
+func (l *loader) LoadNamed(name string, ids interface{}, dst interface{}) Future {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	inv := newInvocation(name, ids, dst)
+	inv.parent = l.batch
+	l.invocations = append(l.invocations, inv)
+	l.pending = append(l.pending, inv)
+	return inv
+}
+
+func (l *loader) LoadFrom(src interface{}, dst interface{}) {
+	if err := loadRelations(l, reflect.ValueOf(src), reflect.ValueOf(dst), true, l.batch.selection); err != nil {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.err == nil {
+			l.err = err
 		}
-		target = target.Elem()
+	}
+}
 
-		resolved, err := l.resolve(ids, target)
-		if err != nil {
-			return err
+// Returns the error of the first failed LoadFrom.
+func (l *loader) error() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.err
+}
+
+// Returns the pending invocations and marks them as scheduled.
+func (l *loader) schedule() []*invocation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	pending := l.pending
+	l.pending = nil
+	return pending
+}
+
+// A resolver is a registered resolver function along with its configuration.
+type resolver struct {
+	name string
+	// the resolved type *T and the key type
+	typ     reflect.Type
+	keyType reflect.Type
+	fn      func(context.Context, *loader, interface{}) (interface{}, error)
+	// whether the resolver returns multiple values for a key
+	many    bool
+	cache   Cache
+	missing MissingPolicy
+	// the maximum number of ids passed to one call of fn, 0 means no limit
+	maxBatchSize     int
+	chunkConcurrency int
+	// the relations of the resolved values not declared by tags
+	relations []Relation
+	stats     resolverStats
+	// the timeout of one call of fn, 0 means no timeout
+	timeout time.Duration
+}
+
+// A batch is one call of a resolver. It resolves the keys missing from the
+// memo for every invocation of the same wave that asked for the resolver.
+type batch struct {
+	ids      reflect.Value
+	resolver *resolver
+	// the union of the selections of the invocations
+	selection Selection
+	// the loaders passed to the resolver calls
+	loaders  []*loader
+	resolved reflect.Value
+	settled  bool
+	// whether the recursion policy cut the expansion of the values, set once
+	// the batch is settled
+	incomplete bool
+	// the depth of the invocations of the batch
+	depth int
+	// the first invocation of the batch
+	cause *invocation
+	// the time spent resolving the batch
+	duration time.Duration
+	// the context of the resolver calls, carrying the span of the batch
+	ctx context.Context
+	// map of key to the first invocation that asked for it
+	causes map[interface{}]*invocation
+	// the resolved struct values sorted by their address, built on demand
+	owners []owner
+}
+
+// An owner is a resolved struct value the destinations of nested invocations
+// can point into.
+type owner struct {
+	start uintptr
+	end   uintptr
+	key   interface{}
+	// the *T value
+	value reflect.Value
+}
+
+// Returns the name of the field of the owner the address points into.
+func (o *owner) field(addr uintptr) string {
+	t := o.value.Elem().Type()
+	offset := addr - o.start
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if offset >= f.Offset && offset < f.Offset+f.Type.Size() {
+			return f.Name
 		}
+	}
 
-		slice := reflect.New(reflect.SliceOf(target)).Elem()
-		for _, k := range resolved.MapKeys() {
-			val := resolved.MapIndex(k)
-			for i := 0; i < val.Len(); i ++ {
-				vv := val.Index(i)
-				if target.Kind() != reflect.Ptr {
-					vv = vv.Elem()
+	return ""
+}
+
+// Returns the resolved value the address points into, nil if there is none.
+func (b *batch) owner(addr uintptr) *owner {
+	if b.owners == nil {
+		b.owners = []owner{}
+		if b.resolver.typ.Elem().Kind() == reflect.Struct && b.resolved.IsValid() {
+			for _, key := range b.resolved.MapKeys() {
+				values := b.resolved.MapIndex(key)
+				for i := 0; i < values.Len(); i++ {
+					if v := values.Index(i); !v.IsNil() {
+						start := v.Pointer()
+						b.owners = append(b.owners, owner{start, start + v.Elem().Type().Size(), key.Interface(), v})
+					}
 				}
-				slice = reflect.Append(slice, vv)
 			}
+			sort.Slice(b.owners, func(i, j int) bool {
+				return b.owners[i].start < b.owners[j].start
+			})
 		}
+	}
 
-		reflect.ValueOf(dst).Elem().Set(slice)
-	default:
-		// TODO: could also be a pointer to interface or scalar
-		if target.Kind() != reflect.Struct {
-			return errors.New("dst must be a pointer to a struct")
+	i := sort.Search(len(b.owners), func(i int) bool {
+		return b.owners[i].end > addr
+	})
+	if i < len(b.owners) && b.owners[i].start <= addr {
+		return &b.owners[i]
+	}
+
+	return nil
+}
+
+// Calls the resolver for the batch ids and collects the nested invocations the
+// resolver issued into the batch loaders.
+func (b *batch) run(ctx context.Context, s *session) (err error) {
+	// don't schedule new batches once the load was cancelled
+	if err := ctx.Err(); err != nil {
+		if context.Cause(ctx) == errLoadTimeout {
+			return &TimeoutError{Name: b.resolver.name, Type: b.resolver.typ.Elem(), KeyType: b.resolver.keyType, Keys: keys(b.ids), Timeout: s.register.loadTimeout, Load: true}
+		}
+		return err
+	}
+
+	// the span of the batch is a child of the span of the batch that issued
+	// its first invocation
+	if b.cause.parent != nil {
+		ctx = b.cause.parent.ctx
+	}
+	if t := s.register.tracer; t != nil {
+		var span Span
+		ctx, span = t.Start(ctx, b.spanName(), b.attributes()...)
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+			}
+			span.End()
+		}()
+	}
+	b.ctx = ctx
+	ctx = WithSelection(ctx, b.selection)
+
+	start := time.Now()
+	defer func() {
+		b.duration = time.Since(start)
+	}()
+
+	size := b.resolver.maxBatchSize
+	if size <= 0 || b.ids.Len() <= size {
+		b.loaders = []*loader{{batch: b}}
+		resolved, err := b.call(ctx, s, b.loaders[0], b.ids)
+		b.resolved = resolved
+		return err
+	}
+
+	// split the ids into chunks of at most size ids, each resolved by its own
+	// resolver call, and merge the results
+	chunks := make([]reflect.Value, (b.ids.Len()+size-1)/size)
+	for i := range chunks {
+		end := (i + 1) * size
+		if end > b.ids.Len() {
+			end = b.ids.Len()
 		}
+		chunks[i] = b.ids.Slice(i*size, end)
+	}
+
+	b.loaders = make([]*loader, len(chunks))
+	results := make([]reflect.Value, len(chunks))
+	if err := parallel(len(chunks), b.resolver.chunkConcurrency, func(i int) error {
+		b.loaders[i] = &loader{batch: b}
+		resolved, err := b.call(ctx, s, b.loaders[i], chunks[i])
+		results[i] = resolved
+		return err
+	}); err != nil {
+		return err
+	}
+
+	b.resolved = reflect.MakeMap(results[0].Type())
+	for _, resolved := range results {
+		for _, key := range resolved.MapKeys() {
+			b.resolved.SetMapIndex(key, resolved.MapIndex(key))
+		}
+	}
+
+	return nil
+}
+
+// Calls the resolver of the batch for the ids. Returns a reflection of
+// map[K][]*T.
+func (b *batch) call(ctx context.Context, s *session, loader *loader, ids reflect.Value) (reflect.Value, error) {
+	if t := b.resolver.timeout; t > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, t, errResolverTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	vals, err := b.invoke(ctx, s, loader, ids)
+	if err != nil {
+		// the error returned by the resolver, if it returned at all
+		cause := err
+		if err == errAbandoned {
+			cause = nil
+		}
+
+		switch context.Cause(ctx) {
+		case errResolverTimeout:
+			err = &TimeoutError{Name: b.resolver.name, Type: b.resolver.typ.Elem(), KeyType: b.resolver.keyType, Keys: keys(ids), Timeout: b.resolver.timeout, Err: cause}
+		case errLoadTimeout:
+			err = &TimeoutError{Name: b.resolver.name, Type: b.resolver.typ.Elem(), KeyType: b.resolver.keyType, Keys: keys(ids), Timeout: s.register.loadTimeout, Load: true, Err: cause}
+		}
+	}
+	b.resolver.stats.record(ids.Len(), time.Since(start), err)
+
+	if err == errAbandoned {
+		return reflect.Value{}, ctx.Err()
+	}
+	if _, ok := err.(*TimeoutError); ok {
+		return reflect.Value{}, err
+	}
+	if err != nil {
+		return reflect.Value{}, &ResolverError{Name: b.resolver.name, Type: b.resolver.typ.Elem(), KeyType: b.resolver.keyType, Keys: keys(ids), Err: err}
+	}
+
+	if err := loader.error(); err != nil {
+		return reflect.Value{}, err
+	}
+
+	// load the tagged relations with the keys on the resolved values
+	resolved := withoutNil(reflect.ValueOf(vals))
+	if b.resolver.typ.Elem().Kind() == reflect.Struct {
+		for _, key := range resolved.MapKeys() {
+			values := resolved.MapIndex(key)
+			for i := 0; i < values.Len(); i++ {
+				if err := loadRelations(loader, values.Index(i), values.Index(i), false, b.selection); err != nil {
+					return reflect.Value{}, err
+				}
+			}
+		}
+	}
+
+	return resolved, nil
+}
+
+// Removes the nil values from the reflection of map[K][]*T. Resolvers return
+// nil values for the keys they found nothing for, so the keys left with no
+// values are removed too and handled by the missing key policy.
+func withoutNil(resolved reflect.Value) reflect.Value {
+	for _, key := range resolved.MapKeys() {
+		values := resolved.MapIndex(key)
+		kept := reflect.MakeSlice(values.Type(), 0, values.Len())
+		for i := 0; i < values.Len(); i++ {
+			if !values.Index(i).IsNil() {
+				kept = reflect.Append(kept, values.Index(i))
+			}
+		}
+
+		switch {
+		case kept.Len() == values.Len():
+		case kept.Len() == 0:
+			resolved.SetMapIndex(key, reflect.Value{})
+		default:
+			resolved.SetMapIndex(key, kept)
+		}
+	}
+
+	return resolved
+}
+
+// Calls the resolver function. If a resolver timeout or a load deadline is
+// set, the resolver is called in its own goroutine and abandoned with
+// errAbandoned once ctx is done, so a resolver ignoring ctx doesn't block the
+// load. A panic propagated by the register is panicked again in the calling
+// goroutine.
+func (b *batch) invoke(ctx context.Context, s *session, loader *loader, ids reflect.Value) (interface{}, error) {
+	if b.resolver.timeout <= 0 && s.register.loadTimeout <= 0 {
+		return b.protect(ctx, s, loader, ids)
+	}
+
+	type result struct {
+		vals interface{}
+		err  error
+		// the value of a propagated panic
+		panic interface{}
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			if v := recover(); v != nil {
+				done <- result{panic: v}
+			}
+		}()
+
+		vals, err := b.protect(ctx, s, loader, ids)
+		done <- result{vals: vals, err: err}
+	}()
+
+	var r result
+	select {
+	case r = <-done:
+	case <-ctx.Done():
+		// prefer the result of a resolver that returned in time
+		select {
+		case r = <-done:
+		default:
+			return nil, errAbandoned
+		}
+	}
+
+	if r.panic != nil {
+		panic(r.panic)
+	}
+	return r.vals, r.err
+}
+
+// Calls the resolver function. A panic of the resolver is returned as a
+// PanicError unless the register re-panics.
+func (b *batch) protect(ctx context.Context, s *session, loader *loader, ids reflect.Value) (vals interface{}, err error) {
+	if !s.register.repanic {
+		defer func() {
+			if v := recover(); v != nil {
+				err = &PanicError{Value: v, Stack: debug.Stack()}
+			}
+		}()
+	}
+
+	return b.resolver.fn(ctx, loader, ids.Interface())
+}
 
-		// build the slice of IDs (containing just the one ID) to resolve
-		slice := reflect.New(reflect.SliceOf(reflect.TypeOf(ids))).Elem()
-		slice = reflect.Append(slice, reflect.ValueOf(ids))
+// A session holds the state of a single load.
+type session struct {
+	register *register
+	memo     memo
+	// the keys not found by the resolvers with the MissingRecord policy
+	notFound []*NotFoundError
+	// all the loaders passed to the resolvers
+	loaders []*loader
+	// all the batches in the order they were run
+	batches []*batch
+}
+
+// Resolves the root invocation and all the nested invocations issued by the
+// resolvers. The values are assigned to the destinations in the order of the
+// ids of the invocations.
+//
+// The nested invocations issued by the resolvers are scheduled breadth-first:
+// all invocations of one depth (wave) across the whole tree are grouped by
+// the type they want to resolve and the type of their keys, so each (type,
+// key type) pair is resolved by exactly one resolver call per wave. Keys
+// already resolved during the load are taken from the memo instead.
+func (l *register) resolve(ctx context.Context, root *invocation) error {
+	return (&session{register: l, memo: memo{}}).resolve(ctx, root)
+}
+
+func (s *session) resolve(ctx context.Context, root *invocation) error {
+	if t := s.register.loadTimeout; t > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, t, errLoadTimeout)
+		defer cancel()
+	}
 
-		resolved, err := l.resolve(slice.Interface(), target)
+	root.selection = SelectionFrom(ctx)
+	for invocations := []*invocation{root}; len(invocations) > 0; {
+		wave, err := s.group(invocations)
 		if err != nil {
 			return err
 		}
 
-		switch resolved.MapIndex(resolved.MapKeys()[0]).Len() {
-		case 0:
-			return errors.New("no data found for key")
-		case 1:
-			reflect.ValueOf(dst).Elem().Set(reflect.Indirect(resolved.MapIndex(resolved.MapKeys()[0]).Index(0)))
-		default:
-			return errors.New("multiple data found for one key")
+		s.batches = append(s.batches, wave...)
+		if err := s.run(ctx, wave); err != nil {
+			return err
+		}
+
+		for _, b := range wave {
+			s.memo.set(b)
+			s.loaders = append(s.loaders, b.loaders...)
 		}
 
+		// the values of the invocations are resolved now, so the callbacks
+		// waiting for them can run
+		for _, inv := range invocations {
+			if err := s.then(inv); err != nil {
+				return err
+			}
+		}
+
+		// the callbacks can call LoadFrom on the loaders of any of the
+		// previous waves
+		invocations = nil
+		for _, l := range s.loaders {
+			if err := l.error(); err != nil {
+				return err
+			}
+			invocations = append(invocations, l.schedule()...)
+		}
+	}
+
+	if err := s.settle(ctx, []*invocation{root}); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, err := range s.notFound {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Runs the batches of one wave. The batches are independent of each other, so
+// they are run in parallel, limited by the concurrency of the register.
+func (s *session) run(ctx context.Context, wave []*batch) error {
+	return parallel(len(wave), s.register.concurrency, func(i int) error {
+		return wave[i].run(ctx, s)
+	})
+}
+
+// Calls fn for 0 <= i < n in at most limit goroutines at once and returns the
+// first error by i. With limit 1 fn is called sequentially, limit <= 0 means
+// no limit.
+func parallel(n int, limit int, fn func(i int) error) error {
+	if limit == 1 || n == 1 {
+		for i := 0; i < n; i++ {
+			if err := fn(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if limit <= 0 {
+		limit = n
+	}
+
+	sem := make(chan struct{}, limit)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = fn(i)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-type (
-	Loader interface {
-		Load(ids interface{}, dst interface{})
+// Returns the resolver registered under the name for the passed type *T. An
+// interface T can also be resolved by the only resolver of a type implementing
+// it.
+func (l *register) resolver(name string, typ reflect.Type, keyType reflect.Type) (*resolver, error) {
+	if r, ok := l.resolvers[name][typ][keyType]; ok {
+		return r, nil
 	}
 
-	loader struct {
-		register    register
-		invocations []invocation
+	if iface := typ.Elem(); iface.Kind() == reflect.Interface {
+		var found []*resolver
+		for t, resolvers := range l.resolvers[name] {
+			r, ok := resolvers[keyType]
+			if ok && (t.Implements(iface) || t.Elem().Implements(iface)) {
+				found = append(found, r)
+			}
+		}
+
+		if len(found) == 1 {
+			return found[0], nil
+		}
+
+		if len(found) > 1 {
+			err := &ConflictError{Name: name, Type: iface, KeyType: keyType}
+			for _, r := range found {
+				err.Types = append(err.Types, r.typ.Elem())
+			}
+			sort.Slice(err.Types, func(i, j int) bool {
+				return err.Types[i].String() < err.Types[j].String()
+			})
+			return nil, err
+		}
 	}
-)
 
-func (l *loader) Load(ids interface{}, dst interface{}) {
-	l.invocations = append(l.invocations, invocation{ids, dst})
+	return nil, &NoResolverError{Name: name, Type: typ.Elem(), KeyType: keyType}
 }
 
-func (l *loader) execute() error {
-	// Group the invocations by the type they want to resolve and the type of
-	// keys they respond to and group the IDs into distinct groups by the type.
-	typeInvocations := map[reflect.Type]map[reflect.Type][]invocation{}
-	// map of return type to map of key type to map of existing keys
-	typeIds := map[reflect.Type]map[reflect.Type]map[interface{}]bool{}
-	for _, inv := range l.invocations {
-		typ := reflect.TypeOf(inv.dst).Elem()
-		if typ.Kind() == reflect.Slice {
-			typ = typ.Elem()
+// Groups the invocations by the resolver they need into batches with distinct
+// ids. Ids already in the memo or in the cache of the resolver are not
+// resolved again.
+func (s *session) group(invocations []*invocation) ([]*batch, error) {
+	var batches []*batch
+	resolverBatches := map[*resolver]*batch{}
+	// map of resolver to map of existing keys
+	resolverIds := map[*resolver]map[interface{}]bool{}
+	for _, inv := range invocations {
+		r, err := s.register.resolver(inv.name, inv.typ, inv.keyType)
+		if err != nil {
+			return nil, err
 		}
-		if typ.Kind() != reflect.Ptr {
-			typ = reflect.PtrTo(typ)
+		inv.resolver = r
+
+		if resolverIds[r] == nil {
+			resolverIds[r] = map[interface{}]bool{}
 		}
 
-		keyType := reflect.TypeOf(inv.ids)
-		if keyType.Kind() == reflect.Slice {
-			keyType = keyType.Elem()
+		// the invocation gets the selection of the field it is loaded into
+		if inv.parent != nil {
+			inv.depth = inv.parent.depth + 1
+			addr := reflect.ValueOf(inv.dst).Pointer()
+			if inv.owner = inv.parent.owner(addr); inv.owner != nil {
+				inv.field = inv.owner.field(addr)
+				inv.selection = inv.parent.selection.Sub(inv.field)
+			}
 		}
 
-		if typeInvocations[typ] == nil {
-			typeInvocations[typ] = map[reflect.Type][]invocation{}
+		if s.register.maxDepth > 0 && inv.depth > s.register.maxDepth {
+			if s.register.depthPolicy == RecursionFail {
+				return nil, &MaxDepthError{Depth: s.register.maxDepth, Path: inv.path()}
+			}
+			inv.stopped = true
+			inv.cut = true
+			continue
 		}
-		if typeIds[typ] == nil {
-			typeIds[typ] = map[reflect.Type]map[interface{}]bool{}
+
+		if s.register.cycles {
+			if err := s.cycles(inv); err != nil {
+				return nil, err
+			}
+			if inv.stopped {
+				continue
+			}
 		}
 
-		typeInvocations[typ][keyType] = append(typeInvocations[typ][keyType], inv)
-		if _, ok := typeIds[typ][keyType]; !ok {
-			typeIds[typ][keyType] = map[interface{}]bool{}
+		// the batch of the invocation, joined once per invocation, so it
+		// resolves the union of the selections of its invocations
+		var b *batch
+		join := func() *batch {
+			if b != nil {
+				return b
+			}
+
+			if b = resolverBatches[r]; b != nil {
+				b.selection = merge(b.selection, inv.selection)
+				return b
+			}
+
+			b = &batch{
+				ids:       reflect.New(reflect.SliceOf(r.keyType)).Elem(),
+				resolver:  r,
+				selection: inv.selection,
+				depth:     inv.depth,
+				cause:     inv,
+				causes:    map[interface{}]*invocation{},
+			}
+			resolverBatches[r] = b
+			batches = append(batches, b)
+			return b
 		}
 
-		if reflect.TypeOf(inv.ids).Kind() == reflect.Slice {
-			for i := 0; i < reflect.ValueOf(inv.ids).Len(); i++ {
-				typeIds[typ][keyType][reflect.ValueOf(inv.ids).Index(i).Interface()] = true
+		hits, misses := 0, 0
+		ids := inv.keys()
+		for i := 0; i < ids.Len(); i++ {
+			id := ids.Index(i)
+			key := id.Interface()
+			seen := resolverIds[r][key]
+			resolverIds[r][key] = true
+
+			if batched := resolverBatches[r]; batched != nil && batched.causes[key] != nil {
+				join()
+				continue
 			}
-		} else {
-			typeIds[typ][keyType][reflect.ValueOf(inv.ids).Interface()] = true
+
+			// the memoized values are used only if they were resolved with
+			// all the fields the invocation selects
+			e, memoized := s.memo.get(r, key)
+			if memoized && covers(e.selection(), inv.selection) {
+				if !seen {
+					hits++
+				}
+				continue
+			}
+			if !seen {
+				misses++
+			}
+
+			if !memoized && r.cache != nil {
+				if v, ok := r.cache.Get(r.cacheKey(key)); ok {
+					s.memo.add(r, key, memoEntry{values: reflect.ValueOf(v)})
+					continue
+				}
+			}
+
+			join()
+			// the values resolved again replace the memoized ones, so they
+			// keep the fields selected before
+			if memoized {
+				b.selection = merge(b.selection, e.selection())
+			}
+			b.ids = reflect.Append(b.ids, id)
+			b.causes[key] = inv
 		}
+		s.register.count(r.typ, hits, misses)
 	}
 
-	for typ, keyTypes := range typeInvocations {
-		for keyType, invocations := range keyTypes {
-			// get the ids for this type
-			ids := reflect.New(reflect.SliceOf(keyType)).Elem()
+	return batches, nil
+}
 
-			for id := range typeIds[typ][keyType] {
-				ids = reflect.Append(ids, reflect.ValueOf(id))
+// Handles the ids of the invocation that are already on its path according to
+// the cycle policy of the register. Stopped ids are removed from the
+// invocation.
+func (s *session) cycles(inv *invocation) error {
+	ids := inv.keys()
+	kept := reflect.MakeSlice(ids.Type(), 0, ids.Len())
+	steps, resolvers := inv.steps()
+	for i := 0; i < ids.Len(); i++ {
+		cycle := -1
+		for j := range steps {
+			if resolvers[j] == inv.resolver && steps[j].Key == ids.Index(i).Interface() {
+				cycle = j
+				break
 			}
+		}
 
-			resolved, err := l.register.resolve(ids.Interface(), typ)
-			if err != nil {
+		if cycle < 0 {
+			kept = reflect.Append(kept, ids.Index(i))
+			continue
+		}
+
+		if s.register.cyclePolicy == RecursionFail {
+			path := append(steps[cycle:], Step{Type: inv.resolver.typ.Elem(), Key: ids.Index(i).Interface()})
+			return &CycleError{Path: path}
+		}
+	}
+
+	switch {
+	case kept.Len() == ids.Len():
+	case reflect.TypeOf(inv.ids).Kind() != reflect.Slice || kept.Len() == 0:
+		inv.stopped = true
+		inv.cut = true
+	default:
+		inv.ids = kept.Interface()
+		inv.cut = true
+	}
+
+	return nil
+}
+
+// Satisfies the invocations. The batches their values come from are settled
+// first, so the values copied into the destinations already contain their own
+// nested data. Settling a batch calls AfterLoad of its values once their nested
+// data is assigned.
+func (s *session) settle(ctx context.Context, invocations []*invocation) error {
+	for _, inv := range invocations {
+		if inv.stopped {
+			continue
+		}
+
+		ids := inv.keys()
+		for i := 0; i < ids.Len(); i++ {
+			e, ok := s.memo.get(inv.resolver, ids.Index(i).Interface())
+			if !ok || e.batch == nil || e.batch.settled {
+				continue
+			}
+
+			e.batch.settled = true
+			for _, l := range e.batch.loaders {
+				if err := s.settle(ctx, l.invocations); err != nil {
+					return err
+				}
+			}
+			e.batch.incomplete = s.incomplete(e.batch)
+
+			if err := afterLoad(ctx, e.batch.resolved); err != nil {
 				return err
 			}
 
-			// satisfy each invocation
-			for _, invocation := range invocations {
-				T := reflect.TypeOf(invocation.dst).Elem()
-				if T.Kind() == reflect.Slice {
-					T = T.Elem()
+			// the values are complete now, so they can be cached unless only
+			// some of their fields were selected or their expansion was cut
+			// by the recursion policy
+			if c := e.batch.resolver.cache; c != nil && e.batch.selection == nil && !e.batch.incomplete {
+				for _, key := range e.batch.resolved.MapKeys() {
+					c.Set(e.batch.resolver.cacheKey(key.Interface()), e.batch.resolved.MapIndex(key).Interface())
 				}
-				pointer := T.Kind() == reflect.Ptr
-				if pointer {
-					T = typ.Elem()
+			}
+		}
+
+		if err := s.satisfy(inv); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Returns whether the recursion policy cut any of the nested invocations of the
+// settled batch, or of the batches their values come from.
+func (s *session) incomplete(b *batch) bool {
+	for _, l := range b.loaders {
+		for _, inv := range l.invocations {
+			if inv.cut {
+				return true
+			}
+
+			ids := inv.keys()
+			for i := 0; i < ids.Len(); i++ {
+				if e, ok := s.memo.get(inv.resolver, ids.Index(i).Interface()); ok && e.batch != nil && e.batch.incomplete {
+					return true
 				}
+			}
+		}
+	}
 
-				if reflect.TypeOf(invocation.dst).Elem().Kind() != reflect.Slice {
-					if reflect.TypeOf(invocation.ids).Kind() == reflect.Slice {
-						return errors.New("cannot fetch multiple ids into one destination")
-					}
+	return false
+}
 
-					rv := resolved.MapIndex(reflect.ValueOf(invocation.ids))
-					switch rv.Len() {
-					case 0:
-						return errors.New("no items found for id")
-					case 1:
-						reflect.ValueOf(invocation.dst).Elem().Set(rv.Index(0).Elem())
-					default:
-						return errors.New("multiple items found for id")
-					}
-				} else {
-					ids := reflect.ValueOf(invocation.ids)
-					if reflect.TypeOf(invocation.ids).Kind() != reflect.Slice {
-						ids = reflect.New(reflect.SliceOf(reflect.TypeOf(invocation.ids))).Elem()
-						ids = reflect.Append(ids, reflect.ValueOf(invocation.ids))
-					}
+// Calls AfterLoad of all the values in the reflection of map[K][]*T that
+// implement AfterLoader.
+func afterLoad(ctx context.Context, resolved reflect.Value) error {
+	// a value can be resolved for multiple keys
+	called := map[interface{}]bool{}
+	for _, key := range resolved.MapKeys() {
+		values := resolved.MapIndex(key)
+		for i := 0; i < values.Len(); i++ {
+			if v, ok := values.Index(i).Interface().(AfterLoader); ok && !called[v] {
+				called[v] = true
+				if err := v.AfterLoad(ctx); err != nil {
+					return err
+				}
+			}
+		}
+	}
 
-					slice := reflect.New(reflect.SliceOf(T)).Elem()
-					for i := 0; i < ids.Len(); i++ {
-						v := resolved.MapIndex(ids.Index(i))
-						if v.Interface() == nil {
-							return errors.New("map index not found")
-						}
+	return nil
+}
 
-						for j := 0; j < v.Len(); j ++ {
-							vv := v.Index(j)
+// Assigns the resolved values to the destination of the invocation, if there
+// are callbacks waiting for them, and runs the callbacks. The nested data of
+// the values is not loaded yet, the destination is assigned again once it is.
+func (s *session) then(invocation *invocation) error {
+	if len(invocation.callbacks) == 0 || invocation.stopped {
+		return nil
+	}
 
-							if !pointer {
-								vv = vv.Elem()
-							}
+	if err := s.satisfy(invocation); err != nil {
+		return err
+	}
 
-							slice = reflect.Append(slice, vv)
-						}
-					}
+	for _, fn := range invocation.callbacks {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Assigns the resolved values to the destination of the invocation.
+func (s *session) satisfy(invocation *invocation) error {
+	r := invocation.resolver
+	dst := reflect.ValueOf(invocation.dst).Elem()
+	if dst.Kind() == reflect.Map {
+		return s.satisfyMap(r, invocation, dst)
+	}
 
-					reflect.ValueOf(invocation.dst).Elem().Set(slice)
+	if dst.Kind() != reflect.Slice {
+		if reflect.TypeOf(invocation.ids).Kind() == reflect.Slice {
+			return errors.New("cannot fetch multiple ids into one destination")
+		}
+
+		e, ok := s.memo.get(r, invocation.ids)
+		if !ok || e.values.Len() == 0 {
+			// nested pointer destinations model optional relations, so they
+			// are left nil for a missing key, which is only recorded with the
+			// MissingRecord policy
+			if dst.Kind() == reflect.Ptr && invocation.parent != nil {
+				dst.Set(reflect.Zero(dst.Type()))
+				if r.missing != MissingRecord {
+					return nil
 				}
+				return s.missing(r, invocation, []interface{}{invocation.ids})
 			}
+
+			if r.missing == MissingZero {
+				dst.Set(reflect.Zero(dst.Type()))
+			}
+			return s.missing(r, invocation, []interface{}{invocation.ids})
 		}
+
+		if e.values.Len() > 1 {
+			return &AmbiguousResultError{Name: r.name, Type: invocation.typ.Elem(), KeyType: invocation.keyType, Keys: []interface{}{invocation.ids}}
+		}
+
+		dst.Set(convert(e.values.Index(0), dst.Type()))
+		return nil
 	}
 
+	ids := invocation.keys()
+	slice := reflect.MakeSlice(dst.Type(), 0, ids.Len())
+	var missing []interface{}
+	for i := 0; i < ids.Len(); i++ {
+		e, ok := s.memo.get(r, ids.Index(i).Interface())
+		if !ok {
+			missing = append(missing, ids.Index(i).Interface())
+			if r.missing == MissingZero && !r.many {
+				slice = reflect.Append(slice, reflect.Zero(dst.Type().Elem()))
+			}
+			continue
+		}
+
+		for j := 0; j < e.values.Len(); j++ {
+			slice = reflect.Append(slice, convert(e.values.Index(j), dst.Type().Elem()))
+		}
+	}
+
+	if err := s.missing(r, invocation, missing); err != nil {
+		return err
+	}
+
+	dst.Set(slice)
 	return nil
 }
 
-// Resolves the ids by calling the resolver for the passed type T or a resolver
-// for the pointer to passed type T. Returns a reflection of map[int64][]*T.
-func (l *register) resolve(ids interface{}, typ reflect.Type) (reflect.Value, error) {
-	if reflect.TypeOf(ids).Kind() != reflect.Slice {
-		return reflect.Value{}, errors.New("ids must be a slice")
+// Assigns the resolved values to the map destination of the invocation, keyed
+// by the ids. The map values can be slices to hold multiple values per key.
+func (s *session) satisfyMap(r *resolver, invocation *invocation, dst reflect.Value) error {
+	if dst.Type().Key() != invocation.keyType {
+		return fmt.Errorf("dst map key type must be %v, got %v", invocation.keyType.String(), dst.Type().Key().String())
 	}
 
+	valType := dst.Type().Elem()
+	elem := valType
+	if valType.Kind() == reflect.Slice {
+		elem = valType.Elem()
+	}
 
-	resolvers, ok := l.resolvers[typ]
-	if !ok {
-		if resolvers, ok = l.resolvers[reflect.PtrTo(typ)]; !ok {
-			return reflect.Value{}, fmt.Errorf("no resolvers found for %v", typ.String())
+	ids := invocation.keys()
+	m := reflect.MakeMapWithSize(dst.Type(), ids.Len())
+	var missing []interface{}
+	for i := 0; i < ids.Len(); i++ {
+		e, ok := s.memo.get(r, ids.Index(i).Interface())
+		if !ok || e.values.Len() == 0 && valType.Kind() != reflect.Slice {
+			missing = append(missing, ids.Index(i).Interface())
+			if r.missing == MissingZero {
+				m.SetMapIndex(ids.Index(i), reflect.Zero(valType))
+			}
+			continue
+		}
+
+		values := reflect.MakeSlice(reflect.SliceOf(elem), 0, e.values.Len())
+		for j := 0; j < e.values.Len(); j++ {
+			values = reflect.Append(values, convert(e.values.Index(j), elem))
+		}
+
+		if valType.Kind() == reflect.Slice {
+			m.SetMapIndex(ids.Index(i), values)
+			continue
 		}
+
+		if values.Len() > 1 {
+			return &AmbiguousResultError{Name: r.name, Type: invocation.typ.Elem(), KeyType: invocation.keyType, Keys: []interface{}{ids.Index(i).Interface()}}
+		}
+		m.SetMapIndex(ids.Index(i), values.Index(0))
 	}
 
-	fn, ok := resolvers[reflect.TypeOf(ids).Elem()]
-	if !ok {
-		return reflect.Value{}, fmt.Errorf("no resolvers found for %v with key type %v", typ.String(), reflect.TypeOf(ids).Elem().String())
+	if err := s.missing(r, invocation, missing); err != nil {
+		return err
 	}
 
-	ldr := &loader{*l, nil}
-	vals, err := fn(context.TODO(), ldr, ids)
-	if err != nil {
-		return reflect.Value{}, err
+	dst.Set(m)
+	return nil
+}
+
+// Converts the resolved value of type *T to the destination type, which is
+// either T, *T or an interface implemented by one of them.
+func convert(v reflect.Value, typ reflect.Type) reflect.Value {
+	switch {
+	case v.Type() == typ:
+		return v
+	case v.Type().Elem() == typ:
+		return v.Elem()
+	case v.Type().AssignableTo(typ):
+		return v
+	default:
+		return v.Elem()
 	}
-	if err := ldr.execute(); err != nil {
-		return reflect.Value{}, err
+}
+
+// Handles the keys of the invocation the resolver found no values for,
+// according to the missing key policy of the resolver.
+func (s *session) missing(r *resolver, invocation *invocation, keys []interface{}) error {
+	if len(keys) == 0 {
+		return nil
 	}
 
-	refVals := reflect.ValueOf(vals)
-	if refVals.Len() != reflect.ValueOf(ids).Len() {
-		return reflect.Value{}, errors.New("not all items found")
+	switch r.missing {
+	case MissingFail:
+		return &NotFoundError{Name: r.name, Type: invocation.typ.Elem(), KeyType: invocation.keyType, Keys: keys}
+	case MissingRecord:
+		for _, err := range s.notFound {
+			if err.Name == r.name && err.Type == invocation.typ.Elem() && err.KeyType == invocation.keyType {
+				err.add(keys)
+				return nil
+			}
+		}
+
+		err := &NotFoundError{Name: r.name, Type: invocation.typ.Elem(), KeyType: invocation.keyType}
+		err.add(keys)
+		s.notFound = append(s.notFound, err)
 	}
 
-	return refVals, nil
+	return nil
 }
 
 type invocation struct {
-	ids interface{}
-	dst interface{}
+	name string
+	ids  interface{}
+	dst  interface{}
+	// the resolved type and the key type of the invocation
+	typ     reflect.Type
+	keyType reflect.Type
+	// the resolver of the invocation, set once the invocation is grouped
+	resolver *resolver
+	// the batch whose resolver issued the invocation, nil for the root
+	parent *batch
+	// the resolved value of the parent batch the destination points into and
+	// the name of its field, if any
+	owner     *owner
+	field     string
+	selection Selection
+	// the depth of the invocation, 0 for the root
+	depth int
+	// whether the invocation was stopped by the recursion policy
+	stopped bool
+	// whether the recursion policy removed any of the ids of the invocation
+	cut bool
+	// called once the values are resolved
+	callbacks []func() error
+}
+
+func (i *invocation) Then(fn func() error) Future {
+	i.callbacks = append(i.callbacks, fn)
+	return i
+}
+
+func newInvocation(name string, ids interface{}, dst interface{}) *invocation {
+	typ, keyType := types(reflect.TypeOf(ids), reflect.TypeOf(dst).Elem())
+	return &invocation{name: name, ids: ids, dst: dst, typ: typ, keyType: keyType}
+}
+
+// Returns the resolved type *T and the key type of a load of ids of type ids
+// into a destination of type dst.
+func types(ids reflect.Type, dst reflect.Type) (reflect.Type, reflect.Type) {
+	typ := dst
+	if typ.Kind() == reflect.Map {
+		typ = typ.Elem()
+	}
+	if typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Ptr {
+		typ = reflect.PtrTo(typ)
+	}
+
+	keyType := ids
+	if keyType.Kind() == reflect.Slice {
+		keyType = keyType.Elem()
+	}
+
+	return typ, keyType
+}
+
+// Returns the reflected slice of ids as []interface{}.
+func keys(ids reflect.Value) []interface{} {
+	keys := make([]interface{}, ids.Len())
+	for i := range keys {
+		keys[i] = ids.Index(i).Interface()
+	}
+
+	return keys
+}
+
+// Returns the ids of the invocation as a slice.
+func (i *invocation) keys() reflect.Value {
+	ids := reflect.ValueOf(i.ids)
+	if ids.Kind() != reflect.Slice {
+		ids = reflect.Append(reflect.New(reflect.SliceOf(ids.Type())).Elem(), ids)
+	}
+
+	return ids
+}
+
+// Returns the resolved values on the path of the invocation, from the root,
+// along with their resolvers. The path ends at the first value the destination
+// of a nested invocation doesn't point into.
+func (i *invocation) steps() ([]Step, []*resolver) {
+	var steps []Step
+	var resolvers []*resolver
+	for inv := i; inv != nil && inv.parent != nil && inv.owner != nil; inv = inv.parent.causes[inv.owner.key] {
+		steps = append(steps, Step{Type: inv.parent.resolver.typ.Elem(), Key: inv.owner.key, Field: inv.field})
+		resolvers = append(resolvers, inv.parent.resolver)
+	}
+
+	for l, r := 0, len(steps)-1; l < r; l, r = l+1, r-1 {
+		steps[l], steps[r] = steps[r], steps[l]
+		resolvers[l], resolvers[r] = resolvers[r], resolvers[l]
+	}
+
+	return steps, resolvers
+}
+
+// Returns the path of the invocation.
+func (i *invocation) path() []Step {
+	steps, _ := i.steps()
+	return steps
 }
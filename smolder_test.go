@@ -1,10 +1,16 @@
 package smolder_test
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/DusanKasan/smolder"
+	"reflect"
+	"sort"
+	"sync"
 	"testing"
+	"time"
 )
 
 type (
@@ -206,4 +212,922 @@ func TestComprehensive(t *testing.T) {
 	}
 
 	t.Log(string(b))
-}
\ No newline at end of file
+}
+type (
+	Employee struct {
+		ID        int64
+		Addresses []Address
+		Employer  Company
+	}
+	Company struct {
+		ID      int64
+		Country Country
+	}
+)
+
+func TestBreadthFirstBatching(t *testing.T) {
+	calls := map[string]int{}
+
+	loader := smolder.New()
+	if err := loader.Register(func(l smolder.Loader, ids []int64) map[int64]*Employee {
+		calls["employees"]++
+		employees := map[int64]*Employee{}
+		for _, u := range db.Users {
+			for _, id := range ids {
+				if u.ID == id {
+					e := &Employee{ID: u.ID}
+					l.Load(u.AddressIDs, &e.Addresses)
+					l.Load(u.ID%2+1, &e.Employer)
+					employees[id] = e
+				}
+			}
+		}
+		return employees
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(func(l smolder.Loader, ids []int64) map[int64]*Address {
+		calls["addresses"]++
+		return loadAddress(l, ids)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(func(l smolder.Loader, ids []int64) map[int64]*Company {
+		calls["companies"]++
+		companies := map[int64]*Company{}
+		for _, id := range ids {
+			c := &Company{ID: id}
+			l.Load(id+1, &c.Country)
+			companies[id] = c
+		}
+		return companies
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(func(ids []int64) map[int64]*Country {
+		calls["countries"]++
+		return loadCountries(ids)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var employees []Employee
+	if err := loader.Load([]int64{1, 2, 3}, &employees); err != nil {
+		t.Fatal(err)
+	}
+
+	// Countries are reached through both addresses and companies on the same
+	// depth, so they must be resolved in a single batch.
+	for name, n := range calls {
+		if n != 1 {
+			t.Errorf("expected 1 call of %v resolver, got %v", name, n)
+		}
+	}
+
+	if len(employees) != 3 {
+		t.Fatalf("expected 3 employees, got %v", len(employees))
+	}
+	for _, e := range employees {
+		if e.Employer.ID != e.ID%2+1 || e.Employer.Country.ID != e.Employer.ID+1 {
+			t.Errorf("unexpected employer of employee %v: %+v", e.ID, e.Employer)
+		}
+		if len(e.Addresses) == 0 {
+			t.Errorf("no addresses loaded for employee %v", e.ID)
+		}
+		for _, a := range e.Addresses {
+			if a.Country.ID == 0 {
+				t.Errorf("no country loaded for address %v of employee %v", a.ID, e.ID)
+			}
+		}
+	}
+}
+
+func TestConcurrentBatches(t *testing.T) {
+	// Addresses and roles of the users are independent, so they are resolved
+	// in parallel. Each of their resolvers waits for the other one to start.
+	var barrier sync.WaitGroup
+	barrier.Add(2)
+	wait := func(name string) {
+		barrier.Done()
+		done := make(chan struct{})
+		go func() {
+			barrier.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Errorf("%v resolver was not run concurrently", name)
+		}
+	}
+
+	loader := smolder.New(smolder.WithConcurrency(2))
+	if err := loader.Register(loadUsers); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(func(l smolder.Loader, ids []int64) map[int64]*Address {
+		wait("address")
+		return loadAddress(l, ids)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadCountries); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(func(users []UserId) map[UserId][]*Role {
+		wait("roles")
+		return loadRoles(users)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var u []Uuser
+	if err := loader.Load([]int64{1, 2}, &u); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, usr := range u {
+		if len(usr.Addresses) == 0 || len(usr.Roles) == 0 {
+			t.Errorf("user %v was not fully loaded: %+v", usr.ID, usr)
+		}
+	}
+}
+
+type ctxKey struct{}
+
+func TestLoadContext(t *testing.T) {
+	loader := smolder.New()
+	if err := loader.Register(func(ctx context.Context, l smolder.Loader, ids []int64) map[int64]*Uuser {
+		if ctx.Value(ctxKey{}) != "request" {
+			t.Error("context value not passed to the users resolver")
+		}
+		return loadUsers(l, ids)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(func(ctx context.Context, l smolder.Loader, ids []int64) map[int64]*Address {
+		if ctx.Value(ctxKey{}) != "request" {
+			t.Error("context value not passed to the addresses resolver")
+		}
+		return loadAddress(l, ids)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(func(ctx context.Context, ids []int64) map[int64]*Country {
+		if ctx.Value(ctxKey{}) != "request" {
+			t.Error("context value not passed to the countries resolver")
+		}
+		return loadCountries(ids)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(func(ctx context.Context, users []UserId) map[UserId][]*Role {
+		if ctx.Value(ctxKey{}) != "request" {
+			t.Error("context value not passed to the roles resolver")
+		}
+		return loadRoles(users)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var u []Uuser
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+	if err := loader.LoadContext(ctx, []int64{1, 2}, &u); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	loader := smolder.New()
+	if err := loader.Register(func(l smolder.Loader, ids []int64) map[int64]*Uuser {
+		cancel()
+		return loadUsers(l, ids)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(func(l smolder.Loader, ids []int64) map[int64]*Address {
+		t.Error("addresses resolved after the context was cancelled")
+		return loadAddress(l, ids)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(func(users []UserId) map[UserId][]*Role {
+		t.Error("roles resolved after the context was cancelled")
+		return loadRoles(users)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var u []Uuser
+	if err := loader.LoadContext(ctx, []int64{1, 2}, &u); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+type Person struct {
+	ID          int64
+	Nationality Country
+	Employer    Company
+}
+
+func TestMemoization(t *testing.T) {
+	var countryCalls [][]int64
+
+	loader := smolder.New()
+	if err := loader.Register(func(l smolder.Loader, ids []int64) map[int64]*Person {
+		people := map[int64]*Person{}
+		for _, id := range ids {
+			p := &Person{ID: id}
+			l.Load(int64(2), &p.Nationality)
+			l.Load(id, &p.Employer)
+			people[id] = p
+		}
+		return people
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(func(l smolder.Loader, ids []int64) map[int64]*Company {
+		companies := map[int64]*Company{}
+		for _, id := range ids {
+			c := &Company{ID: id}
+			l.Load(id+1, &c.Country)
+			companies[id] = c
+		}
+		return companies
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(func(ids []int64) map[int64]*Country {
+		countryCalls = append(countryCalls, ids)
+		return loadCountries(ids)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var people []Person
+	if err := loader.Load([]int64{1, 2}, &people); err != nil {
+		t.Fatal(err)
+	}
+
+	// country 2 is resolved as the nationality in the first wave, so only
+	// country 3 is missing for the companies in the second one
+	if fmt.Sprint(countryCalls) != "[[2] [3]]" {
+		t.Errorf("unexpected country resolver calls: %v", countryCalls)
+	}
+
+	for _, p := range people {
+		if p.Nationality.ID != 2 || p.Employer.Country.ID != p.ID+1 {
+			t.Errorf("unexpected person: %+v", p)
+		}
+	}
+
+	stats := loader.MemoStats()[reflect.TypeOf(Country{})]
+	if stats.Hits != 1 || stats.Misses != 2 {
+		t.Errorf("unexpected memo stats of countries: %+v", stats)
+	}
+}
+
+func TestLoadOrder(t *testing.T) {
+	loader := smolder.New()
+	if err := loader.Register(loadUsers); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadAddress); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadCountries); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadRoles); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		var u []*Uuser
+		if err := loader.Load([]int64{4, 1, 6, 2, 1}, &u); err != nil {
+			t.Fatal(err)
+		}
+
+		var ids, addressIDs []int64
+		for _, usr := range u {
+			ids = append(ids, usr.ID)
+			for _, a := range usr.Addresses {
+				addressIDs = append(addressIDs, a.ID)
+			}
+		}
+
+		if fmt.Sprint(ids) != "[4 1 6 2 1]" {
+			t.Fatalf("users not loaded in the order of ids: %v", ids)
+		}
+		if fmt.Sprint(addressIDs) != "[3 4 1 2 6 2 1 2]" {
+			t.Fatalf("addresses not loaded in the order of ids: %v", addressIDs)
+		}
+	}
+}
+
+func TestMissingPolicy(t *testing.T) {
+	cases := []struct {
+		policy   smolder.MissingPolicy
+		expected string
+		err      bool
+	}{
+		{smolder.MissingFail, "[]", true},
+		{smolder.MissingSkip, "[1 2]", false},
+		{smolder.MissingZero, "[1 0 2]", false},
+		{smolder.MissingRecord, "[1 2]", true},
+	}
+
+	for _, c := range cases {
+		loader := smolder.New()
+		if err := loader.Register(loadAddress, smolder.WithMissingPolicy(c.policy)); err != nil {
+			t.Fatal(err)
+		}
+		if err := loader.Register(loadCountries); err != nil {
+			t.Fatal(err)
+		}
+
+		var addresses []Address
+		err := loader.Load([]int64{1, 99, 2}, &addresses)
+		if (err != nil) != c.err {
+			t.Errorf("policy %v: unexpected error %v", c.policy, err)
+		}
+		if err != nil {
+			var nf *smolder.NotFoundError
+			if !errors.As(err, &nf) || fmt.Sprint(nf.Keys) != "[99]" || nf.Type != reflect.TypeOf(Address{}) {
+				t.Errorf("policy %v: expected not found error for key 99, got %v", c.policy, err)
+			}
+		}
+
+		var ids []int64
+		for _, a := range addresses {
+			ids = append(ids, a.ID)
+		}
+		if fmt.Sprint(ids) != c.expected {
+			t.Errorf("policy %v: expected %v, got %v", c.policy, c.expected, ids)
+		}
+	}
+}
+
+func TestMissingPolicyNilValues(t *testing.T) {
+	cases := []struct {
+		policy   smolder.MissingPolicy
+		expected string
+	}{
+		{smolder.MissingSkip, "[1 2]"},
+		{smolder.MissingZero, "[1 0 2]"},
+	}
+
+	for _, c := range cases {
+		loader := smolder.New()
+		// a nil value means the key was not found
+		if err := loader.Register(func(ids []int64) map[int64]*Country {
+			countries := map[int64]*Country{}
+			for _, id := range ids {
+				if id != 99 {
+					countries[id] = &Country{ID: id}
+				} else {
+					countries[id] = nil
+				}
+			}
+			return countries
+		}, smolder.WithMissingPolicy(c.policy)); err != nil {
+			t.Fatal(err)
+		}
+
+		var countries []Country
+		if err := loader.Load([]int64{1, 99, 2}, &countries); err != nil {
+			t.Errorf("policy %v: unexpected error %v", c.policy, err)
+		}
+
+		var ids []int64
+		for _, c := range countries {
+			ids = append(ids, c.ID)
+		}
+		if fmt.Sprint(ids) != c.expected {
+			t.Errorf("policy %v: expected %v, got %v", c.policy, c.expected, ids)
+		}
+	}
+}
+
+func TestMaxBatchSize(t *testing.T) {
+	for _, concurrency := range []int{1, 0} {
+		var mu sync.Mutex
+		var calls []string
+
+		loader := smolder.New()
+		if err := loader.Register(func(ids []int64) map[int64]*Country {
+			mu.Lock()
+			calls = append(calls, fmt.Sprint(ids))
+			mu.Unlock()
+
+			countries := map[int64]*Country{}
+			for _, id := range ids {
+				countries[id] = &Country{ID: id}
+			}
+			return countries
+		}, smolder.WithMaxBatchSize(3), smolder.WithChunkConcurrency(concurrency)); err != nil {
+			t.Fatal(err)
+		}
+
+		var countries []Country
+		if err := loader.Load([]int64{1, 2, 3, 4, 5, 6, 7}, &countries); err != nil {
+			t.Fatal(err)
+		}
+
+		sort.Strings(calls)
+		if fmt.Sprint(calls) != "[[1 2 3] [4 5 6] [7]]" {
+			t.Errorf("unexpected resolver calls: %v", calls)
+		}
+
+		var ids []int64
+		for _, c := range countries {
+			ids = append(ids, c.ID)
+		}
+		if fmt.Sprint(ids) != "[1 2 3 4 5 6 7]" {
+			t.Errorf("unexpected countries: %v", ids)
+		}
+	}
+}
+
+type Order struct {
+	ID      int64
+	Address Address
+	Region  Country
+}
+
+func TestFutures(t *testing.T) {
+	var countryCalls [][]int64
+
+	loader := smolder.New()
+	if err := loader.Register(func(l smolder.Loader, ids []int64) map[int64]*Order {
+		orders := map[int64]*Order{}
+		for _, id := range ids {
+			o := &Order{ID: id}
+			// the region depends on the loaded address
+			l.Load(id*4-3, &o.Address).Then(func() error {
+				if o.Address.Street == "Hlavna" {
+					l.Load(int64(1), &o.Region)
+				} else {
+					l.Load(int64(2), &o.Region)
+				}
+				return nil
+			})
+			orders[id] = o
+		}
+		return orders
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadAddress); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(func(ids []int64) map[int64]*Country {
+		countryCalls = append(countryCalls, ids)
+		return loadCountries(ids)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var orders []Order
+	if err := loader.Load([]int64{1, 2}, &orders); err != nil {
+		t.Fatal(err)
+	}
+
+	// the regions are batched with the countries of the addresses
+	if len(countryCalls) != 1 {
+		t.Errorf("expected 1 call of the countries resolver, got %v", countryCalls)
+	}
+
+	if orders[0].Address.ID != 1 || orders[0].Address.Country.ID != 1 || orders[0].Region.ID != 1 {
+		t.Errorf("unexpected first order: %+v", orders[0])
+	}
+	if orders[1].Address.ID != 5 || orders[1].Address.Country.ID != 3 || orders[1].Region.ID != 2 {
+		t.Errorf("unexpected second order: %+v", orders[1])
+	}
+}
+
+func TestFutureError(t *testing.T) {
+	failure := errors.New("callback failed")
+
+	loader := smolder.New()
+	if err := loader.Register(func(l smolder.Loader, ids []int64) map[int64]*Order {
+		orders := map[int64]*Order{}
+		for _, id := range ids {
+			o := &Order{ID: id}
+			l.Load(id, &o.Address).Then(func() error {
+				return failure
+			})
+			orders[id] = o
+		}
+		return orders
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadAddress); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadCountries); err != nil {
+		t.Fatal(err)
+	}
+
+	var orders []Order
+	if err := loader.Load([]int64{1}, &orders); err != failure {
+		t.Errorf("expected the callback error, got %v", err)
+	}
+}
+
+type Household struct {
+	ID        int64
+	Members   []Uuser
+	RoleCount int
+}
+
+func (h *Household) AfterLoad(ctx context.Context) error {
+	for _, m := range h.Members {
+		h.RoleCount += len(m.Roles)
+	}
+	return nil
+}
+
+func TestAfterLoad(t *testing.T) {
+	loader := smolder.New()
+	if err := loader.Register(func(l smolder.Loader, ids []int64) map[int64]*Household {
+		households := map[int64]*Household{}
+		for _, id := range ids {
+			h := &Household{ID: id}
+			l.Load([]int64{id*2 - 1, id * 2}, &h.Members)
+			households[id] = h
+		}
+		return households
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadUsers); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadAddress); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadCountries); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadRoles); err != nil {
+		t.Fatal(err)
+	}
+
+	var households []Household
+	if err := loader.Load([]int64{1, 3}, &households); err != nil {
+		t.Fatal(err)
+	}
+
+	// the roles of the members are loaded before AfterLoad of the household
+	if households[0].RoleCount != 3 || households[1].RoleCount != 4 {
+		t.Errorf("unexpected role counts: %v, %v", households[0].RoleCount, households[1].RoleCount)
+	}
+}
+
+type Team struct {
+	ID    int64
+	Roles map[UserId][]Role
+}
+
+func TestMapDestinations(t *testing.T) {
+	loader := smolder.New()
+	if err := loader.Register(func(l smolder.Loader, ids []int64) map[int64]*Team {
+		teams := map[int64]*Team{}
+		for _, id := range ids {
+			team := &Team{ID: id}
+			l.Load([]UserId{{id}, {id + 1}}, &team.Roles)
+			teams[id] = team
+		}
+		return teams
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadUsers); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadAddress); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadCountries); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadRoles); err != nil {
+		t.Fatal(err)
+	}
+
+	var users map[int64]*Uuser
+	if err := loader.Load([]int64{2, 5}, &users); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[2].Name != "ferko" || users[5].Name != "orban" || len(users[5].Roles) != 2 {
+		t.Errorf("unexpected users: %+v", users)
+	}
+
+	var teams map[int64]Team
+	if err := loader.Load([]int64{1}, &teams); err != nil {
+		t.Fatal(err)
+	}
+	roles := teams[1].Roles
+	if len(roles) != 2 || len(roles[UserId{1}]) != 2 || roles[UserId{2}][0].Name != "consumer" {
+		t.Errorf("unexpected team roles: %+v", roles)
+	}
+}
+
+type Named interface {
+	GetName() string
+}
+
+func (c Country) GetName() string {
+	return c.name
+}
+
+type Label string
+
+func (l Label) GetName() string {
+	return string(l)
+}
+
+func TestPointerDestination(t *testing.T) {
+	loader := smolder.New()
+	if err := loader.Register(loadAddress); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadCountries); err != nil {
+		t.Fatal(err)
+	}
+
+	var address *Address
+	if err := loader.Load(int64(3), &address); err != nil {
+		t.Fatal(err)
+	}
+	if address == nil || address.ID != 3 || address.Country.ID != 2 {
+		t.Errorf("unexpected address: %+v", address)
+	}
+}
+
+type Roster struct {
+	ID    int64
+	Names []string
+}
+
+func TestScalarResults(t *testing.T) {
+	loader := smolder.New()
+	if err := loader.Register(func(ids []int64) map[int64]string {
+		names := map[int64]string{}
+		for _, u := range db.Users {
+			for _, id := range ids {
+				if u.ID == id {
+					names[id] = u.Name
+				}
+			}
+		}
+		return names
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(func(l smolder.Loader, ids []int64) map[int64]*Roster {
+		rosters := map[int64]*Roster{}
+		for _, id := range ids {
+			r := &Roster{ID: id}
+			l.Load([]int64{id, id + 1}, &r.Names)
+			rosters[id] = r
+		}
+		return rosters
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	if err := loader.Load([]int64{2, 1}, &names); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(names) != "[ferko janko]" {
+		t.Errorf("unexpected names: %v", names)
+	}
+
+	var name *string
+	if err := loader.Load(int64(3), &name); err != nil {
+		t.Fatal(err)
+	}
+	if name == nil || *name != "bobrze" {
+		t.Errorf("unexpected name: %v", name)
+	}
+
+	var roster Roster
+	if err := loader.Load(int64(4), &roster); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(roster.Names) != "[hryze123 orban]" {
+		t.Errorf("unexpected roster names: %v", roster.Names)
+	}
+}
+
+func TestInterfaceResults(t *testing.T) {
+	loader := smolder.New()
+	if err := loader.Register(func(ids []int64) map[int64]Named {
+		names := map[int64]Named{}
+		for _, id := range ids {
+			names[id] = Label(fmt.Sprint("label ", id))
+		}
+		return names
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []Named
+	if err := loader.Load([]int64{1, 2}, &names); err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[0].GetName() != "label 1" || names[1].GetName() != "label 2" {
+		t.Errorf("unexpected names: %v", names)
+	}
+}
+
+func TestInterfaceDestination(t *testing.T) {
+	loader := smolder.New()
+	if err := loader.Register(loadCountries); err != nil {
+		t.Fatal(err)
+	}
+
+	// the only resolver of a type implementing the interface is used
+	var named Named
+	if err := loader.Load(int64(1), &named); err != nil {
+		t.Fatal(err)
+	}
+	if c, ok := named.(*Country); !ok || c.ID != 1 || named.GetName() != "Slovakia" {
+		t.Errorf("unexpected named: %#v", named)
+	}
+
+	var any []interface{}
+	if err := loader.Load([]int64{2, 3}, &any); err != nil {
+		t.Fatal(err)
+	}
+	if len(any) != 2 || any[0].(*Country).ID != 2 || any[1].(*Country).ID != 3 {
+		t.Errorf("unexpected values: %#v", any)
+	}
+}
+
+type Place struct {
+	ID      int64
+	Country *Country
+}
+
+func TestNullableRelation(t *testing.T) {
+	loader := smolder.New()
+	if err := loader.Register(func(l smolder.Loader, ids []int64) map[int64]*Place {
+		places := map[int64]*Place{}
+		for _, id := range ids {
+			p := &Place{ID: id}
+			l.Load(id, &p.Country)
+			places[id] = p
+		}
+		return places
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadCountries); err != nil {
+		t.Fatal(err)
+	}
+
+	var places []Place
+	if err := loader.Load([]int64{1, 99}, &places); err != nil {
+		t.Fatal(err)
+	}
+
+	if places[0].Country == nil || places[0].Country.ID != 1 {
+		t.Errorf("unexpected country of the first place: %+v", places[0].Country)
+	}
+	if places[1].Country != nil {
+		t.Errorf("expected no country for the second place, got %+v", places[1].Country)
+	}
+}
+
+func TestNullableRelationRecorded(t *testing.T) {
+	loader := smolder.New()
+	if err := loader.Register(func(l smolder.Loader, ids []int64) map[int64]*Place {
+		places := map[int64]*Place{}
+		for _, id := range ids {
+			p := &Place{ID: id}
+			l.Load(id, &p.Country)
+			places[id] = p
+		}
+		return places
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadCountries, smolder.WithMissingPolicy(smolder.MissingRecord)); err != nil {
+		t.Fatal(err)
+	}
+
+	var places []Place
+	err := loader.Load([]int64{1, 99}, &places)
+	var notFound *smolder.NotFoundError
+	if !errors.As(err, &notFound) || len(notFound.Keys) != 1 || notFound.Keys[0] != int64(99) {
+		t.Fatalf("expected the missing country to be recorded, got %v", err)
+	}
+	if len(places) != 2 || places[1].Country != nil {
+		t.Errorf("unexpected places: %+v", places)
+	}
+}
+
+func TestPointerDestinationMissing(t *testing.T) {
+	loader := smolder.New()
+	if err := loader.Register(loadCountries); err != nil {
+		t.Fatal(err)
+	}
+
+	var country *Country
+	if err := loader.Load(int64(99), &country); !errors.Is(err, smolder.ErrNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+type Customer struct {
+	ID       int64
+	Billing  Address
+	Shipping []Address
+}
+
+func TestNamedResolvers(t *testing.T) {
+	var calls []string
+
+	loader := smolder.New()
+	if err := loader.Register(func(l smolder.Loader, ids []int64) map[int64]*Customer {
+		customers := map[int64]*Customer{}
+		for _, id := range ids {
+			c := &Customer{ID: id}
+			l.LoadNamed("billing", id, &c.Billing)
+			l.LoadNamed("shipping", id, &c.Shipping)
+			customers[id] = c
+		}
+		return customers
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// billing address of a customer is the address with the same ID
+	if err := loader.RegisterNamed("billing", func(l smolder.Loader, customerIDs []int64) map[int64]*Address {
+		calls = append(calls, fmt.Sprint("billing ", customerIDs))
+		return loadAddress(l, customerIDs)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// shipping addresses of a customer are the addresses of the user with the
+	// same ID
+	if err := loader.RegisterNamed("shipping", func(l smolder.Loader, customerIDs []int64) map[int64][]*Address {
+		calls = append(calls, fmt.Sprint("shipping ", customerIDs))
+		addresses := map[int64][]*Address{}
+		for _, u := range db.Users {
+			for _, id := range customerIDs {
+				if u.ID == id {
+					for _, a := range loadAddress(l, u.AddressIDs) {
+						addresses[id] = append(addresses[id], a)
+					}
+				}
+			}
+		}
+		return addresses
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadCountries); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := loader.RegisterNamed("billing", loadAddress); err == nil {
+		t.Error("expected an error registering a second billing resolver")
+	}
+
+	var customers []Customer
+	if err := loader.Load([]int64{1, 2}, &customers); err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(calls)
+	if fmt.Sprint(calls) != "[billing [1 2] shipping [1 2]]" {
+		t.Errorf("unexpected resolver calls: %v", calls)
+	}
+	if customers[0].Billing.ID != 1 || len(customers[0].Shipping) != 2 || customers[0].Shipping[0].Country.ID != 1 {
+		t.Errorf("unexpected first customer: %+v", customers[0])
+	}
+	if customers[1].Billing.ID != 2 || len(customers[1].Shipping) != 1 || customers[1].Shipping[0].ID != 2 {
+		t.Errorf("unexpected second customer: %+v", customers[1])
+	}
+
+	var billing Address
+	if err := loader.LoadNamed("billing", int64(3), &billing); err != nil {
+		t.Fatal(err)
+	}
+	if billing.ID != 3 || billing.Country.ID != 2 {
+		t.Errorf("unexpected billing address: %+v", billing)
+	}
+
+	var shipping []Address
+	if err := loader.LoadNamed("gift", []int64{3}, &shipping); !errors.Is(err, smolder.ErrNoResolver) {
+		t.Errorf("expected no resolver error, got %v", err)
+	}
+}
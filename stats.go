@@ -0,0 +1,87 @@
+package smolder
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// the number of the latest resolver call latencies kept for the percentiles
+const latencySamples = 1024
+
+// Stats counts the resolver calls of one resolver.
+type Stats struct {
+	// the number of the resolver calls, each chunk of a batch split by
+	// WithMaxBatchSize being a separate call
+	Batches int
+	// the total number of the keys passed to the resolver
+	Keys int
+	// the most keys passed to one call
+	MaxBatchSize int
+	// the number of the calls that failed
+	Errors int
+	// the total time spent in the calls
+	Latency time.Duration
+	// the 99th percentile of the latency of the latest calls
+	P99 time.Duration
+}
+
+type resolverStats struct {
+	mu    sync.Mutex
+	stats Stats
+	// ring buffer of the latest latencies
+	samples []time.Duration
+	next    int
+}
+
+// Records a resolver call with size keys.
+func (s *resolverStats) record(size int, latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.Batches++
+	s.stats.Keys += size
+	if size > s.stats.MaxBatchSize {
+		s.stats.MaxBatchSize = size
+	}
+	if err != nil {
+		s.stats.Errors++
+	}
+	s.stats.Latency += latency
+
+	if len(s.samples) < latencySamples {
+		s.samples = append(s.samples, latency)
+	} else {
+		s.samples[s.next] = latency
+		s.next = (s.next + 1) % latencySamples
+	}
+}
+
+func (s *resolverStats) get() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := s.stats
+	if len(s.samples) > 0 {
+		samples := append([]time.Duration(nil), s.samples...)
+		sort.Slice(samples, func(i, j int) bool {
+			return samples[i] < samples[j]
+		})
+		stats.P99 = samples[(len(samples)*99+99)/100-1]
+	}
+
+	return stats
+}
+
+// Stats returns the statistics of the calls of all the resolvers called so
+// far, by the resolver name, resolved type and key type.
+func (l *register) Stats() map[Relation]Stats {
+	stats := map[Relation]Stats{}
+	for _, r := range l.all() {
+		if s := r.stats.get(); s.Batches > 0 {
+			stats[Relation{Name: r.name, Type: r.typ.Elem(), KeyType: r.keyType}] = s
+		}
+	}
+
+	return stats
+}
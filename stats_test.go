@@ -0,0 +1,47 @@
+package smolder_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DusanKasan/smolder"
+)
+
+func TestStats(t *testing.T) {
+	loader := smolder.New()
+	if err := loader.Register(func(ids []int64) (map[int64]*Country, error) {
+		time.Sleep(time.Millisecond)
+		if ids[0] == 0 {
+			return nil, errors.New("unavailable")
+		}
+		countries := map[int64]*Country{}
+		for _, id := range ids {
+			countries[id] = &Country{ID: id}
+		}
+		return countries, nil
+	}, smolder.WithMaxBatchSize(2)); err != nil {
+		t.Fatal(err)
+	}
+
+	var countries []Country
+	if err := loader.Load([]int64{1, 2, 3, 4, 5}, &countries); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Load([]int64{0}, &countries); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	stats := loader.Stats()
+	s, ok := stats[smolder.Relation{Type: reflect.TypeOf(Country{}), KeyType: reflect.TypeOf(int64(0))}]
+	if len(stats) != 1 || !ok {
+		t.Fatalf("unexpected stats: %v", stats)
+	}
+	if s.Batches != 4 || s.Keys != 6 || s.MaxBatchSize != 2 || s.Errors != 1 {
+		t.Errorf("unexpected stats: %+v", s)
+	}
+	if s.Latency < 4*time.Millisecond || s.P99 < time.Millisecond || s.P99 > s.Latency {
+		t.Errorf("unexpected latencies: %+v", s)
+	}
+}
@@ -0,0 +1,129 @@
+package smolder
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// A relation is a struct field loaded automatically, described by its tag:
+//
+//	Campaigns []Campaign `smolder:"from=CampaignIDs"`
+//	Billing   Address    `smolder:"from=ID,name=billing"`
+//	Roles     []Role     `smolder:"from=UserID,row"`
+//
+// The keys are taken from the field named by from on the same struct. With the
+// row flag they are taken from the field of the row passed to Loader.LoadFrom
+// instead. The optional name selects a named resolver.
+type relation struct {
+	// index of the tagged field
+	field int
+	from  string
+	name  string
+	// whether the key field is on the row passed to Loader.LoadFrom
+	row bool
+}
+
+// map of struct type to its relations
+var relationsCache sync.Map
+
+// Returns the relations declared by the tags of the struct type t.
+func relations(t reflect.Type) ([]relation, error) {
+	if rels, ok := relationsCache.Load(t); ok {
+		return rels.([]relation), nil
+	}
+
+	var rels []relation
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup("smolder")
+		if !ok {
+			continue
+		}
+
+		if f.PkgPath != "" {
+			return nil, fmt.Errorf("smolder tag on unexported field %v of %v", f.Name, t.String())
+		}
+
+		rel := relation{field: i}
+		for _, opt := range strings.Split(tag, ",") {
+			if strings.TrimSpace(opt) == "row" {
+				rel.row = true
+				continue
+			}
+
+			kv := strings.SplitN(strings.TrimSpace(opt), "=", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("invalid smolder tag option %q on field %v of %v", opt, f.Name, t.String())
+			}
+
+			switch kv[0] {
+			case "from":
+				rel.from = kv[1]
+			case "name":
+				rel.name = kv[1]
+			default:
+				return nil, fmt.Errorf("unknown smolder tag option %q on field %v of %v", kv[0], f.Name, t.String())
+			}
+		}
+
+		if rel.from == "" {
+			return nil, fmt.Errorf("smolder tag on field %v of %v has no from option", f.Name, t.String())
+		}
+		if _, ok := t.FieldByName(rel.from); !ok && !rel.row {
+			return nil, fmt.Errorf("key field %v of the smolder tag on field %v not found on %v", rel.from, f.Name, t.String())
+		}
+
+		rels = append(rels, rel)
+	}
+
+	relationsCache.Store(t, rels)
+	return rels, nil
+}
+
+// Loads the tagged relations of dst, a pointer to a struct, using the keys
+// from the fields of src. If row is set, src is the row passed to
+// Loader.LoadFrom and only the relations with the row flag are loaded,
+// otherwise only the ones without it. Relations with a nil pointer key are
+// skipped, as well as the ones not in the selection.
+func loadRelations(l Loader, src reflect.Value, dst reflect.Value, row bool, selection Selection) error {
+	src = reflect.Indirect(src)
+	if src.Kind() != reflect.Struct {
+		return errors.New("src must be a struct or a pointer to a struct")
+	}
+	if dst.Kind() != reflect.Ptr || dst.Elem().Kind() != reflect.Struct {
+		return errors.New("dst must be a pointer to a struct")
+	}
+
+	rels, err := relations(dst.Elem().Type())
+	if err != nil {
+		return err
+	}
+
+	for _, rel := range rels {
+		if rel.row != row || !selection.Has(dst.Elem().Type().Field(rel.field).Name) {
+			continue
+		}
+
+		key := src.FieldByName(rel.from)
+		if !key.IsValid() {
+			return fmt.Errorf("key field %v not found on %v", rel.from, src.Type().String())
+		}
+		if !key.CanInterface() {
+			return fmt.Errorf("key field %v of %v is unexported", rel.from, src.Type().String())
+		}
+
+		if key.Kind() == reflect.Ptr {
+			if key.IsNil() {
+				continue
+			}
+			key = key.Elem()
+		}
+
+		l.LoadNamed(rel.name, key.Interface(), dst.Elem().Field(rel.field).Addr().Interface())
+	}
+
+	return nil
+}
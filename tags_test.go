@@ -0,0 +1,183 @@
+package smolder_test
+
+import (
+	"github.com/DusanKasan/smolder"
+	"testing"
+)
+
+type (
+	TaggedUser struct {
+		ID         int64
+		AddressIDs []int64
+		Addresses  []TaggedAddress `smolder:"from=AddressIDs"`
+		Roles      []Role          `smolder:"from=UserID,row"`
+	}
+	TaggedAddress struct {
+		ID        int64
+		CountryID *int64
+		Country   *Country `smolder:"from=CountryID"`
+	}
+)
+
+func TestTaggedRelations(t *testing.T) {
+	loader := smolder.New()
+	if err := loader.Register(func(l smolder.Loader, ids []int64) map[int64]*TaggedUser {
+		users := map[int64]*TaggedUser{}
+		for _, u := range db.Users {
+			for _, id := range ids {
+				if u.ID == id {
+					usr := &TaggedUser{ID: u.ID, AddressIDs: u.AddressIDs}
+					// the key of the roles is not a part of the user
+					l.LoadFrom(struct{ UserID UserId }{UserId{u.ID}}, usr)
+					users[id] = usr
+				}
+			}
+		}
+		return users
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(func(ids []int64) map[int64]*TaggedAddress {
+		addresses := map[int64]*TaggedAddress{}
+		for _, a := range db.Addresses {
+			for _, id := range ids {
+				if a.ID == id {
+					addr := &TaggedAddress{ID: a.ID}
+					// the last address has no country
+					if a.ID != 6 {
+						countryID := a.CountryID
+						addr.CountryID = &countryID
+					}
+					addresses[id] = addr
+				}
+			}
+		}
+		return addresses
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadCountries); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadRoles); err != nil {
+		t.Fatal(err)
+	}
+
+	var users []TaggedUser
+	if err := loader.Load([]int64{1, 6}, &users); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(users[0].Addresses) != 2 || users[0].Addresses[1].Country == nil || users[0].Addresses[1].Country.ID != 1 {
+		t.Errorf("unexpected addresses of the first user: %+v", users[0].Addresses)
+	}
+	if len(users[0].Roles) != 2 || users[0].Roles[0].Name != "admin" {
+		t.Errorf("unexpected roles of the first user: %+v", users[0].Roles)
+	}
+	if len(users[1].Addresses) != 1 || users[1].Addresses[0].Country != nil {
+		t.Errorf("unexpected addresses of the second user: %+v", users[1].Addresses)
+	}
+}
+
+type InvalidlyTagged struct {
+	ID      int64
+	Country Country `smolder:"ID"`
+}
+
+func TestInvalidTag(t *testing.T) {
+	loader := smolder.New()
+	if err := loader.Register(func(ids []int64) map[int64]*InvalidlyTagged {
+		m := map[int64]*InvalidlyTagged{}
+		for _, id := range ids {
+			m[id] = &InvalidlyTagged{ID: id}
+		}
+		return m
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var v []InvalidlyTagged
+	if err := loader.Load([]int64{1}, &v); err == nil {
+		t.Error("expected an error for the invalid tag")
+	}
+}
+
+func TestLoadFromError(t *testing.T) {
+	loader := smolder.New()
+	if err := loader.Register(func(l smolder.Loader, ids []int64) map[int64]*Place {
+		places := map[int64]*Place{}
+		for _, id := range ids {
+			p := &Place{ID: id}
+			l.Load(id, &p.Country).Then(func() error {
+				// the src is not a struct
+				l.LoadFrom(42, &TaggedUser{})
+				return nil
+			})
+			places[id] = p
+		}
+		return places
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadCountries); err != nil {
+		t.Fatal(err)
+	}
+
+	var places []Place
+	if err := loader.Load([]int64{1}, &places); err == nil {
+		t.Error("expected the error of LoadFrom called from a callback")
+	}
+}
+
+type MistaggedAddress struct {
+	ID        int64
+	CountryID int64
+	Country   Country `smolder:"from=CountyID"`
+}
+
+func TestUnknownKeyField(t *testing.T) {
+	loader := smolder.New()
+	if err := loader.Register(func(ids []int64) map[int64]*MistaggedAddress {
+		m := map[int64]*MistaggedAddress{}
+		for _, id := range ids {
+			m[id] = &MistaggedAddress{ID: id, CountryID: 1}
+		}
+		return m
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadCountries); err != nil {
+		t.Fatal(err)
+	}
+
+	var addresses []MistaggedAddress
+	if err := loader.Load([]int64{1}, &addresses); err == nil {
+		t.Error("expected an error for the unknown key field")
+	}
+	if err := loader.Validate(); err == nil {
+		t.Error("expected the validation to report the unknown key field")
+	}
+}
+
+func TestLoadFromMissingKeyField(t *testing.T) {
+	loader := smolder.New()
+	if err := loader.Register(func(l smolder.Loader, ids []int64) map[int64]*TaggedUser {
+		users := map[int64]*TaggedUser{}
+		for _, id := range ids {
+			users[id] = &TaggedUser{ID: id}
+			// the row has no UserID field
+			l.LoadFrom(struct{ ID int64 }{id}, users[id])
+		}
+		return users
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadRoles); err != nil {
+		t.Fatal(err)
+	}
+
+	var users []TaggedUser
+	if err := loader.Load([]int64{1}, &users); err == nil {
+		t.Error("expected an error for the key field missing on the row")
+	}
+}
@@ -0,0 +1,160 @@
+package smolder_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DusanKasan/smolder"
+)
+
+func TestTimeout(t *testing.T) {
+	loader := smolder.New()
+	if err := loader.Register(loadAddress); err != nil {
+		t.Fatal(err)
+	}
+	// ignores the context, so it has to be abandoned
+	block := make(chan struct{})
+	defer close(block)
+	if err := loader.Register(func(ids []int64) map[int64]*Country {
+		<-block
+		return loadCountries(ids)
+	}, smolder.WithTimeout(10*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+
+	var addresses []Address
+	err := loader.Load([]int64{1}, &addresses)
+	var timeout *smolder.TimeoutError
+	if !errors.As(err, &timeout) || timeout.Type != reflect.TypeOf(Country{}) || timeout.Timeout != 10*time.Millisecond || timeout.Load {
+		t.Fatalf("expected a timeout of the countries, got %v", err)
+	}
+	if !errors.Is(err, smolder.ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the error to match the timeouts")
+	}
+}
+
+func TestLoadTimeout(t *testing.T) {
+	loader := smolder.New(smolder.WithLoadTimeout(10 * time.Millisecond))
+	if err := loader.Register(loadAddress); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(func(ctx context.Context, ids []int64) (map[int64]*Country, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var addresses []Address
+	err := loader.Load([]int64{1}, &addresses)
+	var timeout *smolder.TimeoutError
+	if !errors.As(err, &timeout) || timeout.Type != reflect.TypeOf(Country{}) || !timeout.Load {
+		t.Fatalf("expected the load to time out on the countries, got %v", err)
+	}
+}
+
+func TestNamedTimeout(t *testing.T) {
+	loader := smolder.New()
+	if err := loader.Register(loadCountries); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.RegisterNamed("slow", func(ctx context.Context, ids []int64) (map[int64]*Country, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, smolder.WithTimeout(10*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+
+	var countries []Country
+	err := loader.LoadNamed("slow", []int64{1}, &countries)
+	var timeout *smolder.TimeoutError
+	if !errors.As(err, &timeout) || timeout.Name != "slow" {
+		t.Fatalf("expected a timeout of the slow resolver, got %v", err)
+	}
+	if expected := `resolver "slow" of smolder_test.Country with key type int64 timed out after 10ms`; err.Error() != expected {
+		t.Errorf("unexpected message: %v", err)
+	}
+}
+
+func TestRepanicWithContext(t *testing.T) {
+	for _, opts := range [][]smolder.RegisterOption{nil, {smolder.WithTimeout(time.Minute)}} {
+		func() {
+			loader := smolder.New(smolder.WithRepanic())
+			if err := loader.Register(func(ids []int64) map[int64]*Country {
+				panic("broken")
+			}, opts...); err != nil {
+				t.Fatal(err)
+			}
+
+			defer func() {
+				if v := recover(); v != "broken" {
+					t.Errorf("expected the panic to be propagated to the caller, got %v", v)
+				}
+			}()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var countries []Country
+			loader.LoadContext(ctx, []int64{1}, &countries)
+			t.Error("expected a panic")
+		}()
+	}
+}
+
+func TestCancelledWithoutTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// without timeouts the resolver is not abandoned once ctx is done
+	loader := smolder.New()
+	if err := loader.Register(func(ids []int64) map[int64]*Country {
+		cancel()
+		time.Sleep(10 * time.Millisecond)
+		return loadCountries(ids)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var countries []Country
+	if err := loader.LoadContext(ctx, []int64{1}, &countries); err != nil {
+		t.Fatal(err)
+	}
+	if len(countries) != 1 {
+		t.Errorf("unexpected countries: %+v", countries)
+	}
+}
+
+type slowError struct{}
+
+func (slowError) Error() string {
+	return "backend too slow"
+}
+
+func TestTimeoutWrapsResolverError(t *testing.T) {
+	loader := smolder.New()
+	if err := loader.Register(func(ctx context.Context, ids []int64) (map[int64]*Country, error) {
+		<-ctx.Done()
+		return nil, slowError{}
+	}, smolder.WithTimeout(10*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+
+	var countries []Country
+	err := loader.Load([]int64{1}, &countries)
+	var timeout *smolder.TimeoutError
+	if !errors.As(err, &timeout) {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+	// the resolver returning at the deadline races with it being abandoned
+	if timeout.Err != nil && !errors.As(err, new(slowError)) {
+		t.Errorf("expected the timeout to wrap the error of the resolver, got %v", timeout.Err)
+	}
+
+	err = &smolder.TimeoutError{Type: reflect.TypeOf(Country{}), KeyType: reflect.TypeOf(int64(0)), Err: slowError{}}
+	if !errors.Is(err, smolder.ErrTimeout) || !errors.As(err, new(slowError)) {
+		t.Errorf("expected the timeout to unwrap to the error of the resolver")
+	}
+}
@@ -0,0 +1,70 @@
+package smolder
+
+import (
+	"context"
+	"fmt"
+)
+
+// Tracer starts a span for every resolver batch of a load. Its shape follows
+// the OpenTelemetry tracer, so an adapter is a few lines long:
+//
+//	func (t otelTracer) Start(ctx context.Context, name string, attrs ...smolder.Attribute) (context.Context, smolder.Span) {
+//		ctx, span := t.tracer.Start(ctx, name)
+//		for _, a := range attrs {
+//			span.SetAttributes(attribute.String(a.Key, fmt.Sprint(a.Value)))
+//		}
+//		return ctx, span
+//	}
+//
+// The context passed to Start carries the span of the batch that issued the
+// loads of the batch, or the span of the caller of Load for the root batch, so
+// the spans mirror the nested load tree. The resolvers get the context
+// returned by Start.
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Span is a traced resolver batch.
+type Span interface {
+	// RecordError records the error the batch failed with.
+	RecordError(err error)
+	// End ends the span once the resolver calls of the batch returned.
+	End()
+}
+
+// Attribute describes a traced batch.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// The keys of the attributes of a traced batch.
+const (
+	// the name of the resolver, empty for unnamed resolvers
+	AttributeName = "smolder.name"
+	// the resolved type
+	AttributeType = "smolder.type"
+	// the key type
+	AttributeKeyType = "smolder.key_type"
+	// the number of the keys passed to the resolver
+	AttributeBatchSize = "smolder.batch_size"
+	// the depth of the loads of the batch, 0 for the root load
+	AttributeDepth = "smolder.depth"
+)
+
+func (b *batch) spanName() string {
+	if b.resolver.name != "" {
+		return fmt.Sprintf("smolder.resolve %v %q", b.resolver.typ.Elem().String(), b.resolver.name)
+	}
+	return fmt.Sprintf("smolder.resolve %v", b.resolver.typ.Elem().String())
+}
+
+func (b *batch) attributes() []Attribute {
+	return []Attribute{
+		{AttributeName, b.resolver.name},
+		{AttributeType, b.resolver.typ.Elem().String()},
+		{AttributeKeyType, b.resolver.keyType.String()},
+		{AttributeBatchSize, b.ids.Len()},
+		{AttributeDepth, b.depth},
+	}
+}
@@ -0,0 +1,121 @@
+package smolder_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/DusanKasan/smolder"
+)
+
+type spanKey struct{}
+
+type recordedSpan struct {
+	name   string
+	attrs  map[string]interface{}
+	parent *recordedSpan
+	err    error
+	ended  bool
+}
+
+func (s *recordedSpan) RecordError(err error) {
+	s.err = err
+}
+
+func (s *recordedSpan) End() {
+	s.ended = true
+}
+
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, attrs ...smolder.Attribute) (context.Context, smolder.Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	span := &recordedSpan{name: name, attrs: map[string]interface{}{}}
+	span.parent, _ = ctx.Value(spanKey{}).(*recordedSpan)
+	for _, a := range attrs {
+		span.attrs[a.Key] = a.Value
+	}
+	t.spans = append(t.spans, span)
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func (t *recordingTracer) span(typ string) *recordedSpan {
+	for _, s := range t.spans {
+		if s.attrs[smolder.AttributeType] == typ {
+			return s
+		}
+	}
+	return nil
+}
+
+func TestTracer(t *testing.T) {
+	tracer := &recordingTracer{}
+	loader := smolder.New(smolder.WithTracer(tracer))
+	if err := loader.Register(loadUsers); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadAddress); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadCountries); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadRoles); err != nil {
+		t.Fatal(err)
+	}
+
+	root := &recordedSpan{}
+	var users []Uuser
+	if err := loader.LoadContext(context.WithValue(context.Background(), spanKey{}, root), []int64{1, 2}, &users); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tracer.spans) != 4 {
+		t.Fatalf("expected a span per batch, got %d", len(tracer.spans))
+	}
+
+	usersSpan, addresses, roles, countries := tracer.span("smolder_test.Uuser"), tracer.span("smolder_test.Address"), tracer.span("smolder_test.Role"), tracer.span("smolder_test.Country")
+	if usersSpan == nil || addresses == nil || roles == nil || countries == nil {
+		t.Fatalf("missing spans: %+v", tracer.spans)
+	}
+	if usersSpan.parent != root || addresses.parent != usersSpan || roles.parent != usersSpan || countries.parent != addresses {
+		t.Errorf("the span hierarchy doesn't mirror the load tree")
+	}
+	if usersSpan.attrs[smolder.AttributeBatchSize] != 2 || addresses.attrs[smolder.AttributeBatchSize] != 2 {
+		t.Errorf("unexpected batch sizes: %v, %v", usersSpan.attrs, addresses.attrs)
+	}
+	if countries.attrs[smolder.AttributeDepth] != 2 || countries.attrs[smolder.AttributeKeyType] != "int64" {
+		t.Errorf("unexpected attributes: %v", countries.attrs)
+	}
+	for _, s := range tracer.spans {
+		if !s.ended || s.err != nil {
+			t.Errorf("unexpected state of the span %v", s.name)
+		}
+	}
+}
+
+func TestTracerError(t *testing.T) {
+	tracer := &recordingTracer{}
+	loader := smolder.New(smolder.WithTracer(tracer))
+	if err := loader.Register(func(ids []int64) (map[int64]*Country, error) {
+		return nil, errors.New("unavailable")
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var countries []Country
+	if err := loader.Load([]int64{1}, &countries); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if len(tracer.spans) != 1 || !errors.Is(tracer.spans[0].err, smolder.ErrResolver) || !tracer.spans[0].ended {
+		t.Errorf("expected the error to be recorded, got %+v", tracer.spans)
+	}
+}
@@ -0,0 +1,148 @@
+package smolder
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Relation is a load of a type by a key type, using the resolver registered
+// under the name.
+type Relation struct {
+	// the name of the resolver, empty for unnamed resolvers
+	Name string
+	// the resolved type
+	Type    reflect.Type
+	KeyType reflect.Type
+}
+
+// Relate returns the relation of a load of ids into dst using the resolver
+// registered under the name, as in Loader.LoadNamed. Only the types of ids and
+// dst matter, e.g. Relate("", []int64(nil), &[]User{}).
+func Relate(name string, ids interface{}, dst interface{}) Relation {
+	typ, keyType := types(reflect.TypeOf(ids), reflect.TypeOf(dst).Elem())
+	return Relation{Name: name, Type: typ.Elem(), KeyType: keyType}
+}
+
+// A relation to validate along with a description of where it comes from.
+type edge struct {
+	Relation
+	from string
+}
+
+// Validate checks the relation graph of the register. Starting from the roots
+// declared by WithRoots, or from every registered resolver if there are none,
+// it follows the relations declared by the tags of the resolved types and by
+// WithRelations. It reports the relations with no resolver or with conflicting
+// resolvers, the invalid tags and, if the roots are declared, the resolvers
+// none of the relations reaches.
+func (l *register) Validate() error {
+	var queue []edge
+	for _, rel := range l.roots {
+		queue = append(queue, edge{rel, "root"})
+	}
+	if len(queue) == 0 {
+		for _, r := range l.all() {
+			queue = append(queue, edge{Relation{r.name, r.typ.Elem(), r.keyType}, "register"})
+		}
+	}
+
+	var errs []error
+	seen := map[Relation]bool{}
+	reached := map[*resolver]bool{}
+	for len(queue) > 0 {
+		e := queue[0]
+		queue = queue[1:]
+		if seen[e.Relation] {
+			continue
+		}
+		seen[e.Relation] = true
+
+		r, err := l.resolver(e.Name, reflect.PtrTo(e.Type), e.KeyType)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v: %w", e.from, err))
+			continue
+		}
+		if reached[r] {
+			continue
+		}
+		reached[r] = true
+
+		rels, err := r.edges()
+		if err != nil {
+			errs = append(errs, err)
+		}
+		queue = append(queue, rels...)
+	}
+
+	if len(l.roots) > 0 {
+		for _, r := range l.all() {
+			if !reached[r] {
+				errs = append(errs, &UnreachableError{Name: r.name, Type: r.typ.Elem(), KeyType: r.keyType})
+			}
+		}
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Error() < errs[j].Error()
+	})
+
+	return errors.Join(errs...)
+}
+
+// Returns all the registered resolvers.
+func (l *register) all() []*resolver {
+	var all []*resolver
+	for _, types := range l.resolvers {
+		for _, resolvers := range types {
+			for _, r := range resolvers {
+				all = append(all, r)
+			}
+		}
+	}
+
+	return all
+}
+
+// Returns the relations of the resolved values, declared by their tags and by
+// WithRelations. The tagged relations with the row flag are loaded by
+// Loader.LoadFrom, so their key type is unknown.
+func (r *resolver) edges() ([]edge, error) {
+	var edges []edge
+	for _, rel := range r.relations {
+		edges = append(edges, edge{rel, fmt.Sprintf("relation of %v", r.typ.Elem().String())})
+	}
+
+	t := r.typ.Elem()
+	if t.Kind() != reflect.Struct {
+		return edges, nil
+	}
+
+	rels, err := relations(t)
+	if err != nil {
+		return edges, err
+	}
+
+	for _, rel := range rels {
+		if rel.row {
+			continue
+		}
+
+		from, ok := t.FieldByName(rel.from)
+		if !ok {
+			return edges, fmt.Errorf("key field %v of the smolder tag on field %v not found on %v", rel.from, t.Field(rel.field).Name, t.String())
+		}
+
+		keyType := from.Type
+		if keyType.Kind() == reflect.Ptr {
+			keyType = keyType.Elem()
+		}
+
+		f := t.Field(rel.field)
+		typ, keyType := types(keyType, f.Type)
+		edges = append(edges, edge{Relation{rel.name, typ.Elem(), keyType}, fmt.Sprintf("field %v of %v", f.Name, t.String())})
+	}
+
+	return edges, nil
+}
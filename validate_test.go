@@ -0,0 +1,94 @@
+package smolder_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/DusanKasan/smolder"
+)
+
+func loadTaggedUsers(l smolder.Loader, ids []int64) map[int64]*TaggedUser {
+	users := map[int64]*TaggedUser{}
+	for _, id := range ids {
+		users[id] = &TaggedUser{ID: id}
+		l.LoadFrom(struct{ UserID UserId }{UserId{id}}, users[id])
+	}
+	return users
+}
+
+func loadTaggedAddresses(ids []int64) map[int64]*TaggedAddress {
+	addresses := map[int64]*TaggedAddress{}
+	for _, id := range ids {
+		addresses[id] = &TaggedAddress{ID: id}
+	}
+	return addresses
+}
+
+func TestValidate(t *testing.T) {
+	loader := smolder.New(smolder.WithRoots(smolder.Relate("", []int64(nil), &[]TaggedUser{})))
+	roles := smolder.WithRelations(smolder.Relate("", UserId{}, &[]Role{}))
+	if err := loader.Register(loadTaggedUsers, roles); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadTaggedAddresses); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadCountries); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadRoles); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := loader.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	loader := smolder.New(smolder.WithRoots(smolder.Relate("", []int64(nil), &[]TaggedUser{})))
+	// the roles are loaded by LoadFrom but not declared
+	if err := loader.Register(loadTaggedUsers); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadTaggedAddresses); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(loadRoles); err != nil {
+		t.Fatal(err)
+	}
+
+	err := loader.Validate()
+	var noResolver *smolder.NoResolverError
+	if !errors.As(err, &noResolver) || noResolver.Type != reflect.TypeOf(Country{}) || noResolver.KeyType != reflect.TypeOf(int64(0)) {
+		t.Errorf("expected the missing resolver of the countries, got %v", err)
+	}
+	var unreachable *smolder.UnreachableError
+	if !errors.As(err, &unreachable) || unreachable.Type != reflect.TypeOf(Role{}) {
+		t.Errorf("expected the resolver of the roles to be unreachable, got %v", err)
+	}
+}
+
+func TestValidateConflict(t *testing.T) {
+	loader := smolder.New(smolder.WithRoots(smolder.Relate("", int64(0), new(Named))))
+	if err := loader.Register(loadCountries); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Register(func(ids []int64) map[int64]Label {
+		labels := map[int64]Label{}
+		for _, id := range ids {
+			labels[id] = Label(fmt.Sprint("label ", id))
+		}
+		return labels
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := loader.Validate()
+	var conflict *smolder.ConflictError
+	if !errors.As(err, &conflict) || len(conflict.Types) != 2 {
+		t.Errorf("expected a conflict, got %v", err)
+	}
+}